@@ -2,17 +2,19 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/big"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/ArnavChoudhary9/PebbleDB/internal/tracing"
 	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -47,32 +49,74 @@ type JWKSCache struct {
 var jwksCache = &JWKSCache{}
 
 // FetchJWKS fetches JWKS from the given URL with caching
-func FetchJWKS(jwksUrl string) (*JWKS, error) {
+func FetchJWKS(ctx context.Context, jwksUrl string) (_ *JWKS, err error) {
+	ctx, span := tracing.Start(ctx, "auth.FetchJWKS", tracing.KindInternal)
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
 	jwksCache.mutex.RLock()
 	if jwksCache.keys != nil && time.Now().Before(jwksCache.expiresAt) {
 		keys := jwksCache.keys
 		jwksCache.mutex.RUnlock()
-		log.Printf("Using cached JWKS keys")
+		span.SetAttributes("cache_hit", true)
 		return keys, nil
 	}
 	jwksCache.mutex.RUnlock()
+	span.SetAttributes("cache_hit", false)
 
 	jwksCache.mutex.Lock()
 	defer jwksCache.mutex.Unlock()
 
 	// Double-check after acquiring write lock
 	if jwksCache.keys != nil && time.Now().Before(jwksCache.expiresAt) {
-		log.Printf("Using cached JWKS keys (double-check)")
 		return jwksCache.keys, nil
 	}
 
-	log.Printf("Fetching JWKS from: %s", jwksUrl)
+	slog.Info("Fetching JWKS", "url", jwksUrl)
+	jwks, err := downloadJWKS(ctx, jwksUrl)
+	jwksFetches.Inc(outcome(err))
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache for 1 hour
+	jwksCache.keys = jwks
+	jwksCache.expiresAt = time.Now().Add(1 * time.Hour)
+
+	slog.Info("Cached JWKS keys", "count", len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		slog.Debug("JWKS key", "kid", key.Kid, "alg", key.Alg, "kty", key.Kty, "crv", key.Crv)
+	}
+
+	return jwks, nil
+}
 
+// JWKSCacheState returns the number of cached JWKS keys and when the cache
+// expires; zero values mean nothing is cached
+func JWKSCacheState() (keys int, expiresAt time.Time) {
+	jwksCache.mutex.RLock()
+	defer jwksCache.mutex.RUnlock()
+	if jwksCache.keys == nil {
+		return 0, time.Time{}
+	}
+	return len(jwksCache.keys.Keys), jwksCache.expiresAt
+}
+
+// downloadJWKS fetches and decodes the JWKS at jwksUrl
+func downloadJWKS(ctx context.Context, jwksUrl string) (*JWKS, error) {
 	client := &http.Client{
 		Timeout: 10 * time.Second,
 	}
 
-	resp, err := client.Get(jwksUrl)
+	req, err := http.NewRequestWithContext(ctx, "GET", jwksUrl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch JWKS: %v", err)
 	}
@@ -86,16 +130,6 @@ func FetchJWKS(jwksUrl string) (*JWKS, error) {
 	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
 		return nil, fmt.Errorf("failed to decode JWKS: %v", err)
 	}
-
-	// Cache for 1 hour
-	jwksCache.keys = &jwks
-	jwksCache.expiresAt = time.Now().Add(1 * time.Hour)
-
-	log.Printf("Cached %d JWKS keys", len(jwks.Keys))
-	for i, key := range jwks.Keys {
-		log.Printf("Key %d: ID=%s, Algorithm=%s, Type=%s, Curve=%s", i, key.Kid, key.Alg, key.Kty, key.Crv)
-	}
-
 	return &jwks, nil
 }
 
@@ -173,7 +207,13 @@ func VerifyJWT(tokenString string, jwks *JWKS) (*jwt.Token, error) {
 }
 
 // RefreshAccessToken refreshes the access token using the refresh token
-func RefreshAccessToken(refreshToken, refreshUrl, tokenRefreshKey string) (*types.RefreshTokenResponse, error) {
+func RefreshAccessToken(ctx context.Context, refreshToken, refreshUrl, tokenRefreshKey string) (_ *types.RefreshTokenResponse, err error) {
+	ctx, span := tracing.Start(ctx, "auth.RefreshAccessToken", tracing.KindClient, "url.full", refreshUrl)
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
 	reqBody := types.RefreshTokenRequest{
 		RefreshToken: refreshToken,
 	}
@@ -187,10 +227,11 @@ func RefreshAccessToken(refreshToken, refreshUrl, tokenRefreshKey string) (*type
 		Timeout: 10 * time.Second,
 	}
 
-	req, err := http.NewRequest("POST", refreshUrl, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", refreshUrl, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
+	tracing.Inject(ctx, req.Header)
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("apikey", tokenRefreshKey)
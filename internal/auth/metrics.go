@@ -0,0 +1,21 @@
+package auth
+
+import "github.com/ArnavChoudhary9/PebbleDB/internal/metrics"
+
+// Calls to the identity provider, by outcome: "success" or "error"
+var (
+	jwksFetches = metrics.NewCounterVec("pebbledb_jwks_fetches_total",
+		"JWKS downloads from the identity provider, by outcome. Cache hits are not counted.",
+		"outcome")
+	tokenRefreshes = metrics.NewCounterVec("pebbledb_token_refreshes_total",
+		"Access token refreshes attempted for expired tokens, by outcome.",
+		"outcome")
+)
+
+// outcome returns the outcome label for err
+func outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
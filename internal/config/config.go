@@ -1,54 +1,427 @@
 package config
 
 import (
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
-// Config holds all environment variables
+// Config holds the server configuration. Settings are layered, each layer
+// overriding the previous one: built-in defaults, the config file, environment
+// variables (including a .env file) and command-line flags.
+//
+// Every setting is addressed as "section.key" in files and flags, e.g.
+// server.listen_addr in YAML/TOML/JSON and --server.listen_addr on the
+// command line. The env tag names its environment variable. Settings tagged
+// reload can be changed while the server runs; see Manager.
 type Config struct {
-	JWKSUrl         string
-	AuthTokenName   string
-	TokenRefreshUrl string
-	TokenRefreshKey string
-	CookieDomain    string
+	Server    ServerConfig    `config:"server"`
+	Auth      AuthConfig      `config:"auth"`
+	Storage   StorageConfig   `config:"storage"`
+	Limits    LimitsConfig    `config:"limits"`
+	RateLimit RateLimitConfig `config:"rate_limit"`
+	CORS      CORSConfig      `config:"cors"`
+	Backups   BackupsConfig   `config:"backups"`
+	Logging   LoggingConfig   `config:"logging"`
+	Metrics   MetricsConfig   `config:"metrics"`
+	Tracing   TracingConfig   `config:"tracing"`
+
+	File        string // Config file the settings were read from, if any
+	PrintConfig bool   // Set by --print-config
+}
+
+// ServerConfig holds listener and lifecycle settings
+type ServerConfig struct {
+	ListenAddr      string        `config:"listen_addr" env:"LISTEN_ADDR"`               // TCP address to listen on
+	UnixSocket      string        `config:"unix_socket" env:"UNIX_SOCKET"`               // Unix domain socket path; replaces ListenAddr when set
+	TLSCertFile     string        `config:"tls_cert_file" env:"TLS_CERT_FILE"`           // PEM certificate; TLS is enabled when set with TLSKeyFile
+	TLSKeyFile      string        `config:"tls_key_file" env:"TLS_KEY_FILE"`             // PEM private key
+	TLSClientCAFile string        `config:"tls_client_ca_file" env:"TLS_CLIENT_CA_FILE"` // PEM CA bundle used to require and verify client certificates
+	ShutdownTimeout time.Duration `config:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`     // How long in-flight requests may take to drain on shutdown
+
+	Compression        bool `config:"compression" env:"COMPRESSION"`                   // Compress responses with gzip or deflate when clients accept it
+	CompressionLevel   int  `config:"compression_level" env:"COMPRESSION_LEVEL"`       // 1 (fastest) to 9 (smallest)
+	CompressionMinSize int  `config:"compression_min_size" env:"COMPRESSION_MIN_SIZE"` // Responses shorter than this many bytes are sent uncompressed
+}
+
+// AuthConfig holds authentication settings
+type AuthConfig struct {
+	Enabled         bool   `config:"enabled" env:"AUTH_ENABLED"`                              // Disable only for local development
+	DevUser         string `config:"dev_user" env:"AUTH_DEV_USER"`                            // User ID assigned to every request when auth is disabled
+	JWKSURL         string `config:"jwks_url" env:"JWKS_URL"`                                 // JSON Web Key Set used to verify access tokens
+	TokenName       string `config:"token_name" env:"AUTH_TOKEN_NAME"`                        // Name of the auth token cookie
+	TokenRefreshURL string `config:"token_refresh_url" env:"TOKEN_REFRESH_URL"`               // Endpoint used to refresh expired access tokens
+	TokenRefreshKey string `config:"token_refresh_key" env:"TOKEN_REFRESH_KEY" secret:"true"` // API key sent to the refresh endpoint
+	CookieDomain    string `config:"cookie_domain" env:"COOKIE_DOMAIN"`                       // Domain of refreshed auth cookies
+}
+
+// StorageConfig holds data directory and project database settings
+type StorageConfig struct {
+	DataDir         string        `config:"data_dir" env:"DATA_DIR"`                      // Root of all project metadata and databases
+	MaxOpenConns    int           `config:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`       // Per project database
+	MaxIdleConns    int           `config:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`       // Per project database
+	ConnMaxLifetime time.Duration `config:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"` // Zero keeps connections open indefinitely
+	WALMode         bool          `config:"wal_mode" env:"DB_WAL_MODE"`                   // Open project databases in WAL journal mode
+	ForeignKeys     bool          `config:"foreign_keys" env:"DB_FOREIGN_KEYS"`           // Enforce foreign key constraints
+	MinFreeDisk     int64         `config:"min_free_disk" env:"MIN_FREE_DISK"`            // Free bytes below which /readyz reports the server not ready
 }
 
-// LoadConfig loads environment variables and returns a Config struct
-func LoadConfig() *Config {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("Warning: .env file not found or could not be loaded")
+// LimitsConfig holds request and result size limits
+type LimitsConfig struct {
+	MaxUploadSize   int64 `config:"max_upload_size" env:"MAX_UPLOAD_SIZE" reload:"true"`     // Largest uploaded database file, in bytes
+	MaxBodySize     int64 `config:"max_body_size" env:"MAX_BODY_SIZE" reload:"true"`         // Largest JSON request body, in bytes
+	MaxJSONDepth    int   `config:"max_json_depth" env:"MAX_JSON_DEPTH" reload:"true"`       // Deepest nesting of arrays and objects in data and where_args
+	MaxDataFields   int   `config:"max_data_fields" env:"MAX_DATA_FIELDS" reload:"true"`     // Most columns in a request's data object
+	MaxWhereArgs    int   `config:"max_where_args" env:"MAX_WHERE_ARGS" reload:"true"`       // Most values in a request's where_args
+	DefaultPageSize int   `config:"default_page_size" env:"DEFAULT_PAGE_SIZE" reload:"true"` // Rows returned by a list request without ?limit
+	MaxPageSize     int   `config:"max_page_size" env:"MAX_PAGE_SIZE" reload:"true"`         // Largest ?limit accepted for buffered list responses
+	MaxStreamedRows int   `config:"max_streamed_rows" env:"MAX_STREAMED_ROWS" reload:"true"` // Rows after which a streamed response is truncated
+	StrictJSON      bool  `config:"strict_json" env:"STRICT_JSON" reload:"true"`             // Reject unknown fields and trailing data in JSON request bodies
+}
+
+// RateLimitConfig holds per-minute request limits. Reads, Writes and DDL apply
+// separately to every user and client IP, and the Project limits to every
+// project. Zero disables the limit for a class.
+type RateLimitConfig struct {
+	Enabled       bool `config:"enabled" env:"RATE_LIMIT_ENABLED" reload:"true"`               // Reject requests over the limits with 429
+	Reads         int  `config:"reads" env:"RATE_LIMIT_READS" reload:"true"`                   // Queries and metadata lookups per minute
+	Writes        int  `config:"writes" env:"RATE_LIMIT_WRITES" reload:"true"`                 // Row inserts, updates and deletes per minute
+	DDL           int  `config:"ddl" env:"RATE_LIMIT_DDL" reload:"true"`                       // Table and project creation and deletion per minute
+	ProjectReads  int  `config:"project_reads" env:"RATE_LIMIT_PROJECT_READS" reload:"true"`   // Reads per minute against a single project
+	ProjectWrites int  `config:"project_writes" env:"RATE_LIMIT_PROJECT_WRITES" reload:"true"` // Writes per minute against a single project
+	ProjectDDL    int  `config:"project_ddl" env:"RATE_LIMIT_PROJECT_DDL" reload:"true"`       // DDL requests per minute against a single project
+}
+
+// CORSConfig holds the cross-origin policy for browser clients
+type CORSConfig struct {
+	AllowedOrigins   []string      `config:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" reload:"true"`     // Origins, "https://*.example.com" patterns or "*"
+	AllowCredentials bool          `config:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" reload:"true"` // Allow cookies on cross-origin requests; requires explicit origins
+	AllowedMethods   []string      `config:"allowed_methods" env:"CORS_ALLOWED_METHODS" reload:"true"`     // Methods allowed in preflight requests
+	AllowedHeaders   []string      `config:"allowed_headers" env:"CORS_ALLOWED_HEADERS" reload:"true"`     // Request headers allowed in preflight requests; "*" allows any
+	ExposedHeaders   []string      `config:"exposed_headers" env:"CORS_EXPOSED_HEADERS" reload:"true"`     // Response headers readable by scripts
+	MaxAge           time.Duration `config:"max_age" env:"CORS_MAX_AGE" reload:"true"`                     // How long browsers may cache preflight results
+	Routes           []string      `config:"routes" env:"CORS_ROUTES" reload:"true"`                       // "<route pattern> <origin>..." entries allowing other origins on a route
+}
+
+// RouteOrigins parses Routes into the allowed origins of each route pattern.
+// Entries without a pattern or origins are ignored; Validate reports them.
+func (c CORSConfig) RouteOrigins() map[string][]string {
+	routes := make(map[string][]string, len(c.Routes))
+	for _, entry := range c.Routes {
+		if words := strings.Fields(entry); len(words) >= 2 {
+			routes[words[0]] = words[1:]
+		}
 	}
+	return routes
+}
 
+// BackupsConfig holds scheduled backup settings
+type BackupsConfig struct {
+	Enabled  bool          `config:"enabled" env:"BACKUPS_ENABLED"`   // Periodically back up every project database
+	Dir      string        `config:"dir" env:"BACKUPS_DIR"`           // Defaults to <data_dir>/backups
+	Interval time.Duration `config:"interval" env:"BACKUPS_INTERVAL"` // Time between backup runs
+	Retain   int           `config:"retain" env:"BACKUPS_RETAIN"`     // Backups kept per project
+}
+
+// LoggingConfig holds log output settings
+type LoggingConfig struct {
+	Level  string `config:"level" env:"LOG_LEVEL" reload:"true"` // debug, info, warn or error
+	Format string `config:"format" env:"LOG_FORMAT"`             // text or json
+}
+
+// MetricsConfig holds metrics endpoint settings
+type MetricsConfig struct {
+	Enabled         bool `config:"enabled" env:"METRICS_ENABLED"`                     // Serve Prometheus metrics at /metrics without authentication
+	PerProjectPools bool `config:"per_project_pools" env:"METRICS_PER_PROJECT_POOLS"` // Also export connection pool statistics labelled by user and project
+}
+
+// TracingConfig holds request tracing settings
+type TracingConfig struct {
+	Enabled     bool    `config:"enabled" env:"TRACING_ENABLED"`           // Record spans and export them to Endpoint
+	Endpoint    string  `config:"endpoint" env:"TRACING_ENDPOINT"`         // OTLP/HTTP traces URL, e.g. http://localhost:4318/v1/traces, or a file path for OTLP/JSON lines
+	SampleRatio float64 `config:"sample_ratio" env:"TRACING_SAMPLE_RATIO"` // Fraction of new traces recorded, 0 to 1
+	ServiceName string  `config:"service_name" env:"TRACING_SERVICE_NAME"` // service.name resource attribute
+}
+
+// Default returns the configuration used when nothing overrides it
+func Default() *Config {
 	return &Config{
-		JWKSUrl:         os.Getenv("JWKS_URL"),
-		AuthTokenName:   os.Getenv("AUTH_TOKEN_NAME"),
-		TokenRefreshUrl: os.Getenv("TOKEN_REFRESH_URL"),
-		TokenRefreshKey: os.Getenv("TOKEN_REFRESH_KEY"),
-		CookieDomain:    os.Getenv("COOKIE_DOMAIN"),
+		Server: ServerConfig{
+			ListenAddr:         ":8080",
+			ShutdownTimeout:    30 * time.Second,
+			Compression:        true,
+			CompressionLevel:   6,
+			CompressionMinSize: 1024,
+		},
+		Auth: AuthConfig{
+			Enabled: true,
+			DevUser: "local",
+		},
+		Storage: StorageConfig{
+			DataDir:         "pdb_data",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: time.Hour,
+			WALMode:         true,
+			ForeignKeys:     true,
+			MinFreeDisk:     100 << 20,
+		},
+		Limits: LimitsConfig{
+			MaxUploadSize:   512 << 20,
+			MaxBodySize:     4 << 20,
+			MaxJSONDepth:    8,
+			MaxDataFields:   1000,
+			MaxWhereArgs:    1000,
+			DefaultPageSize: 100,
+			MaxPageSize:     1000,
+			MaxStreamedRows: 1000000,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:       true,
+			Reads:         1200,
+			Writes:        600,
+			DDL:           60,
+			ProjectReads:  600,
+			ProjectWrites: 300,
+			ProjectDDL:    30,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Content-Type", "Authorization", "If-None-Match", "X-Request-Id", "traceparent"},
+			ExposedHeaders: []string{"X-Request-Id", "ETag", "Content-Disposition", "Retry-After",
+				"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"},
+			MaxAge: 10 * time.Minute,
+		},
+		Backups: BackupsConfig{
+			Interval: 24 * time.Hour,
+			Retain:   7,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Tracing: TracingConfig{
+			SampleRatio: 1,
+			ServiceName: "pebbledb",
+		},
 	}
 }
 
-// Validate checks if all required environment variables are set
+// Load builds the configuration from defaults, the config file, the
+// environment and the command-line arguments (without the program name).
+// The config file is taken from --config or PEBBLEDB_CONFIG.
+func Load(args []string) (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn(".env file could not be loaded", "error", err)
+	}
+
+	cfg := Default()
+	settings := cfg.settings()
+
+	// Flags are parsed first to find the config file, but applied last
+	fs := flag.NewFlagSet("pebbledb", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.StringVar(&cfg.File, "config", os.Getenv("PEBBLEDB_CONFIG"), "path to a YAML, TOML or JSON config file")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", false, "print the effective configuration with secrets redacted and exit")
+	flagValues := map[string]string{}
+	for _, s := range sortedSettings(settings) {
+		key := s.key
+		record := func(value string) error {
+			flagValues[key] = value
+			return nil
+		}
+		if s.value.Kind() == reflect.Bool {
+			fs.BoolFunc(key, s.help, record)
+		} else {
+			fs.Func(key, s.help, record)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("invalid command line: %w", err)
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("invalid command line: unexpected argument %q", fs.Arg(0))
+	}
+
+	var problems []string
+	if cfg.File != "" {
+		fileValues, err := readFile(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(fileValues))
+		for key := range fileValues {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value := fileValues[key]
+			s, ok := settings[key]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown setting %s", cfg.File, key))
+				continue
+			}
+			if err := s.set(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s: %v", cfg.File, key, err))
+			}
+		}
+	}
+
+	for _, s := range sortedSettings(settings) {
+		if value, ok := os.LookupEnv(s.env); ok && s.env != "" {
+			if err := s.set(value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", s.env, err))
+			}
+		}
+	}
+
+	for _, s := range sortedSettings(settings) {
+		if value, ok := flagValues[s.key]; ok {
+			if err := s.set(value); err != nil {
+				problems = append(problems, fmt.Sprintf("--%s: %v", s.key, err))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	if cfg.Backups.Dir == "" {
+		cfg.Backups.Dir = filepath.Join(cfg.Storage.DataDir, "backups")
+	}
+	return cfg, nil
+}
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks the configuration and reports all problems at once
 func (c *Config) Validate() error {
-	if c.JWKSUrl == "" {
-		return fmt.Errorf("JWKS_URL environment variable is required")
+	var problems []string
+	require := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	// Server
+	require(c.Server.ListenAddr != "" || c.Server.UnixSocket != "", "server.listen_addr or server.unix_socket is required")
+	require((c.Server.TLSCertFile == "") == (c.Server.TLSKeyFile == ""), "server.tls_cert_file and server.tls_key_file must be set together")
+	require(c.Server.TLSClientCAFile == "" || c.Server.TLSCertFile != "", "server.tls_client_ca_file requires server.tls_cert_file and server.tls_key_file")
+	require(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	require(c.Server.CompressionLevel >= 1 && c.Server.CompressionLevel <= 9, "server.compression_level must be between 1 and 9")
+	require(c.Server.CompressionMinSize >= 0, "server.compression_min_size must not be negative")
+
+	// Auth
+	if c.Auth.Enabled {
+		require(isURL(c.Auth.JWKSURL), "auth.jwks_url must be an http(s) URL (JWKS_URL)")
+		require(c.Auth.TokenName != "", "auth.token_name is required (AUTH_TOKEN_NAME)")
+		require(isURL(c.Auth.TokenRefreshURL), "auth.token_refresh_url must be an http(s) URL (TOKEN_REFRESH_URL)")
+		require(c.Auth.TokenRefreshKey != "", "auth.token_refresh_key is required (TOKEN_REFRESH_KEY)")
+		require(c.Auth.CookieDomain != "", "auth.cookie_domain is required (COOKIE_DOMAIN)")
+	} else {
+		require(c.Auth.DevUser != "", "auth.dev_user is required when auth is disabled")
 	}
-	if c.AuthTokenName == "" {
-		return fmt.Errorf("AUTH_TOKEN_NAME environment variable is required")
+
+	// Storage
+	require(c.Storage.DataDir != "", "storage.data_dir is required")
+	require(c.Storage.MaxOpenConns > 0, "storage.max_open_conns must be positive")
+	require(c.Storage.MaxIdleConns >= 0, "storage.max_idle_conns must not be negative")
+	require(c.Storage.ConnMaxLifetime >= 0, "storage.conn_max_lifetime must not be negative")
+	require(c.Storage.MinFreeDisk >= 0, "storage.min_free_disk must not be negative")
+
+	// Limits
+	require(c.Limits.MaxUploadSize > 0, "limits.max_upload_size must be positive")
+	require(c.Limits.MaxBodySize > 0, "limits.max_body_size must be positive")
+	require(c.Limits.MaxJSONDepth > 0, "limits.max_json_depth must be positive")
+	require(c.Limits.MaxDataFields > 0, "limits.max_data_fields must be positive")
+	require(c.Limits.MaxWhereArgs > 0, "limits.max_where_args must be positive")
+	require(c.Limits.DefaultPageSize > 0, "limits.default_page_size must be positive")
+	require(c.Limits.MaxPageSize >= c.Limits.DefaultPageSize, "limits.max_page_size must be at least limits.default_page_size")
+	require(c.Limits.MaxStreamedRows > 0, "limits.max_streamed_rows must be positive")
+
+	// Rate limits
+	require(c.RateLimit.Reads >= 0, "rate_limit.reads must not be negative")
+	require(c.RateLimit.Writes >= 0, "rate_limit.writes must not be negative")
+	require(c.RateLimit.DDL >= 0, "rate_limit.ddl must not be negative")
+	require(c.RateLimit.ProjectReads >= 0, "rate_limit.project_reads must not be negative")
+	require(c.RateLimit.ProjectWrites >= 0, "rate_limit.project_writes must not be negative")
+	require(c.RateLimit.ProjectDDL >= 0, "rate_limit.project_ddl must not be negative")
+
+	// CORS
+	checkOrigins := func(key string, origins []string) {
+		for _, origin := range origins {
+			require(origin == "*" || strings.Contains(origin, "://"), "%s: %q must be \"*\" or include a scheme, e.g. https://app.example.com", key, origin)
+			require(strings.Count(origin, "*") <= 1, "%s: %q may contain at most one *", key, origin)
+			require(origin != "*" || !c.CORS.AllowCredentials, "%s must list origins explicitly when cors.allow_credentials is set", key)
+		}
 	}
-	if c.TokenRefreshUrl == "" {
-		return fmt.Errorf("TOKEN_REFRESH_URL environment variable is required")
+	checkOrigins("cors.allowed_origins", c.CORS.AllowedOrigins)
+	for _, entry := range c.CORS.Routes {
+		words := strings.Fields(entry)
+		require(len(words) >= 2 && strings.HasPrefix(words[0], "/"), "cors.routes: %q must be a route pattern followed by origins, e.g. \"/api/export https://reports.example.com\"", entry)
+		if len(words) >= 2 {
+			checkOrigins("cors.routes "+words[0], words[1:])
+		}
 	}
-	if c.TokenRefreshKey == "" {
-		return fmt.Errorf("TOKEN_REFRESH_KEY environment variable is required")
+	require(c.CORS.MaxAge >= 0, "cors.max_age must not be negative")
+
+	// Backups
+	if c.Backups.Enabled {
+		require(c.Backups.Dir != "", "backups.dir is required when backups are enabled")
+		require(c.Backups.Interval >= time.Minute, "backups.interval must be at least 1m")
+		require(c.Backups.Retain > 0, "backups.retain must be positive")
 	}
-	if c.CookieDomain == "" {
-		return fmt.Errorf("COOKIE_DOMAIN environment variable is required")
+
+	// Logging
+	switch strings.ToLower(c.Logging.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, fmt.Sprintf("logging.level must be debug, info, warn or error, got %q", c.Logging.Level))
+	}
+	switch strings.ToLower(c.Logging.Format) {
+	case "text", "json":
+	default:
+		problems = append(problems, fmt.Sprintf("logging.format must be text or json, got %q", c.Logging.Format))
+	}
+
+	// Tracing
+	if c.Tracing.Enabled {
+		require(c.Tracing.Endpoint != "", "tracing.endpoint is required when tracing is enabled")
+		require(c.Tracing.ServiceName != "", "tracing.service_name is required when tracing is enabled")
+	}
+	require(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
 	}
 	return nil
 }
+
+// isURL reports whether s is an absolute http or https URL
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redacted replaces secret values in printed configurations
+const redacted = "<redacted>"
+
+// setting is a single configurable value, addressed as "section.key"
+type setting struct {
+	key    string
+	env    string
+	help   string
+	secret bool
+	reload bool // Can be applied without a restart
+	order  int
+	value  reflect.Value
+}
+
+// settings maps every "section.key" to its field in c
+func (c *Config) settings() map[string]*setting {
+	settings := map[string]*setting{}
+	root := reflect.ValueOf(c).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Type().Field(i).Tag.Get("config")
+		if section == "" {
+			continue
+		}
+		sv := root.Field(i)
+		for j := 0; j < sv.NumField(); j++ {
+			field := sv.Type().Field(j)
+			s := &setting{
+				key:    section + "." + field.Tag.Get("config"),
+				env:    field.Tag.Get("env"),
+				secret: field.Tag.Get("secret") == "true",
+				reload: field.Tag.Get("reload") == "true",
+				order:  len(settings),
+				value:  sv.Field(j),
+			}
+			s.help = "overrides " + s.key
+			if s.env != "" {
+				s.help += " and $" + s.env
+			}
+			settings[s.key] = s
+		}
+	}
+	return settings
+}
+
+// sortedSettings returns settings in declaration order
+func sortedSettings(settings map[string]*setting) []*setting {
+	list := make([]*setting, 0, len(settings))
+	for _, s := range settings {
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].order < list[j].order })
+	return list
+}
+
+// set parses raw, a string or a list of strings, into the setting's field
+func (s *setting) set(raw interface{}) error {
+	if list, ok := raw.([]string); ok {
+		if s.value.Kind() != reflect.Slice {
+			return fmt.Errorf("expected a single value, got a list")
+		}
+		s.value.Set(reflect.ValueOf(append([]string(nil), list...)))
+		return nil
+	}
+
+	str := strings.TrimSpace(raw.(string))
+	switch {
+	case s.value.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", str)
+		}
+		s.value.SetInt(int64(d))
+	case s.value.Kind() == reflect.String:
+		s.value.SetString(str)
+	case s.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", str)
+		}
+		s.value.SetBool(b)
+	case s.value.Kind() == reflect.Int, s.value.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", str)
+		}
+		s.value.SetInt(n)
+	case s.value.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", str)
+		}
+		s.value.SetFloat(f)
+	case s.value.Kind() == reflect.Slice:
+		var list []string
+		for _, item := range strings.Split(str, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		s.value.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+	return nil
+}
+
+// format renders the setting's value as YAML, redacting secrets
+func (s *setting) format() string {
+	if s.secret && !s.value.IsZero() {
+		return strconv.Quote(redacted)
+	}
+	switch v := s.value.Interface().(type) {
+	case time.Duration:
+		return v.String()
+	case string:
+		return strconv.Quote(v)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, item := range v {
+			quoted[i] = strconv.Quote(item)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Print writes the configuration as YAML with secrets redacted. The output can
+// be used as a config file.
+func (c *Config) Print(w io.Writer) error {
+	section := ""
+	for _, s := range sortedSettings(c.settings()) {
+		name, key, _ := strings.Cut(s.key, ".")
+		if name != section {
+			if section != "" {
+				if _, err := fmt.Fprintln(w); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprintf(w, "%s:\n", name); err != nil {
+				return err
+			}
+			section = name
+		}
+		if _, err := fmt.Fprintf(w, "  %s: %s\n", key, s.format()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
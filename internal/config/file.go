@@ -0,0 +1,220 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Config files hold one level of sections with scalar or list values. Only
+// that subset of YAML and TOML is supported:
+//
+//	# YAML                     # TOML                     // JSON
+//	server:                    [server]                   {"server": {
+//	  listen_addr: ":8080"     listen_addr = ":8080"        "listen_addr": ":8080"}}
+//
+// Values are returned keyed by "section.key" as a string or []string.
+
+// readFile parses a config file, choosing the format from its extension
+func readFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var values map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		values, err = parseJSON(data)
+	case ".yaml", ".yml":
+		values, err = parseYAML(data)
+	case ".toml":
+		values, err = parseTOML(data)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q (use .yaml, .toml or .json)", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// parseJSON reads {"section": {"key": value}}
+func parseJSON(data []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var sections map[string]map[string]interface{}
+	if err := dec.Decode(&sections); err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	for section, entries := range sections {
+		for key, raw := range entries {
+			value, err := jsonValue(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", section, key, err)
+			}
+			values[section+"."+key] = value
+		}
+	}
+	return values, nil
+}
+
+// jsonValue converts a decoded JSON scalar or array to a setting value
+func jsonValue(raw interface{}) (interface{}, error) {
+	switch v := raw.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []interface{}:
+		list := make([]string, len(v))
+		for i, item := range v {
+			s, err := jsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			str, ok := s.(string)
+			if !ok {
+				return nil, fmt.Errorf("lists may not be nested")
+			}
+			list[i] = str
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("unsupported value %v", raw)
+	}
+}
+
+// parseYAML reads top-level sections of "key: value" pairs. Lists are written
+// inline as [a, b] or as "- item" lines below the key.
+func parseYAML(data []byte) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	section, listKey := "", ""
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(stripComment(line), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		indented := line[0] == ' ' || line[0] == '\t'
+
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok && indented && listKey != "" {
+			list, _ := values[listKey].([]string)
+			values[listKey] = append(list, unquote(item))
+			continue
+		}
+		listKey = ""
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", n+1)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		if !indented {
+			if value != "" {
+				return nil, fmt.Errorf("line %d: %s must be a section", n+1, key)
+			}
+			section = key
+			continue
+		}
+		if section == "" {
+			return nil, fmt.Errorf("line %d: %s is not inside a section", n+1, key)
+		}
+
+		path := section + "." + key
+		if value == "" {
+			// A list follows on the next lines
+			values[path] = []string{}
+			listKey = path
+			continue
+		}
+		values[path] = scalarOrList(value)
+	}
+	return values, nil
+}
+
+// parseTOML reads [section] tables of key = value pairs
+func parseTOML(data []byte) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	section := ""
+	for n, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(stripComment(line))
+		if trimmed == "" {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "[") {
+			if !strings.HasSuffix(trimmed, "]") {
+				return nil, fmt.Errorf("line %d: invalid section header", n+1)
+			}
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", n+1)
+		}
+		if section == "" {
+			return nil, fmt.Errorf("line %d: %s is not inside a section", n+1, strings.TrimSpace(key))
+		}
+		values[section+"."+strings.TrimSpace(key)] = scalarOrList(strings.TrimSpace(value))
+	}
+	return values, nil
+}
+
+// scalarOrList parses an inline [a, "b"] list or a single, possibly quoted, value
+func scalarOrList(value string) interface{} {
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return unquote(value)
+	}
+	list := []string{}
+	for _, item := range strings.Split(value[1:len(value)-1], ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, unquote(item))
+		}
+	}
+	return list
+}
+
+// unquote removes double or single quotes around a value
+func unquote(value string) string {
+	if len(value) >= 2 {
+		switch {
+		case value[0] == '"' && value[len(value)-1] == '"':
+			if s, err := strconv.Unquote(value); err == nil {
+				return s
+			}
+		case value[0] == '\'' && value[len(value)-1] == '\'':
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+// stripComment removes a # comment that is not inside quotes
+func stripComment(line string) string {
+	quote := byte(0)
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
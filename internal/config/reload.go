@@ -0,0 +1,151 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Manager holds the running configuration and reloads it on request. Only
+// settings tagged reload are swapped in; changes to any other setting are
+// reported as requiring a restart and otherwise ignored.
+type Manager struct {
+	args    []string
+	current atomic.Pointer[Config]
+
+	mu      sync.Mutex // Serializes reloads
+	hooks   []func(*Config)
+	modTime time.Time // Config file modification time at the last load
+}
+
+// ReloadResult lists the settings that changed in a reload
+type ReloadResult struct {
+	Applied         []string // Reloadable settings now in effect
+	RestartRequired []string // Changed settings that only apply after a restart
+}
+
+// NewManager wraps a configuration loaded from args so it can be reloaded
+// with the same command line
+func NewManager(cfg *Config, args []string) *Manager {
+	m := &Manager{args: args}
+	m.current.Store(cfg)
+	m.modTime = fileModTime(cfg.File)
+	return m
+}
+
+// Current returns the configuration in effect. The returned value must not be modified.
+func (m *Manager) Current() *Config {
+	return m.current.Load()
+}
+
+// OnReload registers fn to be called with the new configuration after every
+// reload that changes a reloadable setting
+func (m *Manager) OnReload(fn func(*Config)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, fn)
+}
+
+// Reload loads and validates the configuration again and atomically swaps in
+// the reloadable settings. An invalid configuration leaves the current one in place.
+func (m *Manager) Reload() (ReloadResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Record the file version being loaded so Watch does not retry a bad file
+	m.modTime = fileModTime(m.Current().File)
+
+	var result ReloadResult
+	loaded, err := Load(m.args)
+	if err != nil {
+		return result, err
+	}
+	if err := loaded.Validate(); err != nil {
+		return result, err
+	}
+
+	old := m.Current()
+	next := *old
+	nextSettings := next.settings()
+	loadedSettings := loaded.settings()
+	for _, s := range sortedSettings(nextSettings) {
+		value := loadedSettings[s.key].value
+		if reflect.DeepEqual(s.value.Interface(), value.Interface()) {
+			continue
+		}
+		if s.reload {
+			s.value.Set(value)
+			result.Applied = append(result.Applied, s.key)
+		} else {
+			result.RestartRequired = append(result.RestartRequired, s.key)
+		}
+	}
+
+	if len(result.Applied) > 0 {
+		m.current.Store(&next)
+		for _, hook := range m.hooks {
+			hook(&next)
+		}
+	}
+	return result, nil
+}
+
+// Watch reloads the configuration whenever the config file changes, checking
+// every interval until ctx is done. It does nothing without a config file.
+func (m *Manager) Watch(ctx context.Context, interval time.Duration) {
+	file := m.Current().File
+	if file == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.mu.Lock()
+			changed := fileModTime(file) != m.modTime
+			m.mu.Unlock()
+			if changed {
+				slog.Info("Config file changed, reloading", "file", file)
+				m.ReloadAndLog()
+			}
+		}
+	}
+}
+
+// ReloadAndLog reloads the configuration and logs the outcome
+func (m *Manager) ReloadAndLog() {
+	result, err := m.Reload()
+	if err != nil {
+		slog.Error("Configuration reload failed, keeping the current configuration", "error", err)
+		return
+	}
+	if len(result.Applied) == 0 && len(result.RestartRequired) == 0 {
+		slog.Info("Configuration reloaded, no changes")
+	}
+	if len(result.Applied) > 0 {
+		slog.Info("Configuration reloaded", "applied", result.Applied)
+	}
+	if len(result.RestartRequired) > 0 {
+		slog.Warn("Configuration changes require a restart", "settings", result.RestartRequired)
+	}
+}
+
+// fileModTime returns the modification time of a file, or zero if it cannot be read
+func fileModTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/tracing"
+)
+
+// backupTimeFormat names backup files so that they sort chronologically
+const backupTimeFormat = "20060102T150405Z"
+
+// BackupSchedule describes periodic backups of every project database
+type BackupSchedule struct {
+	DataDir  string        // Working directory holding projects/<user>/<project>.db
+	Dir      string        // Backups are written to Dir/<user>/<project>-<time>.db
+	Interval time.Duration // Time between runs
+	Retain   int           // Backups kept per project
+}
+
+// RunBackups backs up all project databases every schedule.Interval until ctx is done
+func RunBackups(ctx context.Context, schedule BackupSchedule) {
+	ticker := time.NewTicker(schedule.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := BackupAllProjects(ctx, schedule); err != nil {
+				slog.Error("Scheduled backup failed", "error", err)
+			} else {
+				slog.Info("Scheduled backup completed", "dir", schedule.Dir)
+			}
+		}
+	}
+}
+
+// BackupAllProjects writes a backup of every project database and prunes old
+// backups beyond schedule.Retain. It continues past failing projects.
+func BackupAllProjects(ctx context.Context, schedule BackupSchedule) (err error) {
+	ctx, span := tracing.Start(ctx, "database.BackupAllProjects", tracing.KindInternal)
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	projectsPath := filepath.Join(schedule.DataDir, "projects")
+	files, err := filepath.Glob(filepath.Join(projectsPath, "*", "*.db"))
+	if err != nil {
+		return err
+	}
+
+	stamp := time.Now().UTC().Format(backupTimeFormat)
+	var errs []error
+	for _, file := range files {
+		// Stop between projects when cancelled, never in the middle of a copy
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+
+		userID := filepath.Base(filepath.Dir(file))
+		projectID := strings.TrimSuffix(filepath.Base(file), ".db")
+
+		// Skip upload and download temporaries and databases left behind by
+		// deleted projects, which have no project directory
+		if !ValidProjectID(projectID) {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(projectsPath, userID, projectID)); err != nil || !info.IsDir() {
+			continue
+		}
+
+		if err := backupProject(ctx, file, userID, projectID, stamp, schedule); err != nil {
+			errs = append(errs, fmt.Errorf("%s/%s: %w", userID, projectID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// backupProject backs up the project database at path and prunes old copies.
+// It opens its own connection, closed afterwards, so backups do not keep
+// every project open in the connection pool.
+func backupProject(ctx context.Context, path, userID, projectID, stamp string, schedule BackupSchedule) error {
+	projectDBs.RLock()
+	cfg := projectDBConfig
+	projectDBs.RUnlock()
+	cfg.Path = path
+	cfg.MaxOpenConns, cfg.MaxIdleConns = 1, 1
+
+	db, err := NewDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	db = db.WithContext(context.WithoutCancel(ctx))
+
+	dir := filepath.Join(schedule.Dir, userID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := db.BackupTo(filepath.Join(dir, projectID+"-"+stamp+".db")); err != nil {
+		return err
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, projectID+"-*.db"))
+	if err != nil {
+		return err
+	}
+
+	// Skip backups of other projects whose ID shares this prefix
+	var backups []string
+	for _, match := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), projectID+"-"), ".db")
+		if _, err := time.Parse(backupTimeFormat, suffix); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	sort.Strings(backups)
+	for len(backups) > schedule.Retain {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
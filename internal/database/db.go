@@ -1,18 +1,42 @@
 package database
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// DB represents a SQLite database wrapper
+// DB represents a SQLite database wrapper. Views created by WithContext share
+// the connection pool and run their statements within a context.
 type DB struct {
+	*pool
+	ctx context.Context // Nil outside a WithContext view
+}
+
+// pool is the state shared by a database and all its views
+type pool struct {
 	conn *sql.DB
 	path string
+
+	// Column metadata cached per table for strict writes, valid while the
+	// database's schema_version is schemaVersion
+	schemaMu      sync.Mutex
+	schemaCache   map[string][]ColumnInfo
+	schemaVersion int64
+
+	// Identify the data for DataVersion: a random value per open, so versions
+	// never repeat across reopens, and a count of write statements
+	epoch   string
+	changes atomic.Uint64
 }
 
 // Config holds database configuration options
@@ -64,10 +88,36 @@ func NewDB(config Config) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &DB{
-		conn: conn,
-		path: config.Path,
-	}, nil
+	epoch := make([]byte, 8)
+	rand.Read(epoch)
+
+	return &DB{pool: &pool{
+		conn:        conn,
+		path:        config.Path,
+		schemaCache: make(map[string][]ColumnInfo),
+		epoch:       hex.EncodeToString(epoch),
+	}}, nil
+}
+
+// DataVersion identifies the current contents of the database. It changes
+// after every statement that may write through this DB, including committed
+// transactions, and whenever the database is reopened.
+func (db *DB) DataVersion() string {
+	return db.epoch + "-" + strconv.FormatUint(db.changes.Load(), 10)
+}
+
+// WithContext returns a view of the database whose statements run within ctx,
+// so they are cancelled with it and traced as children of its span
+func (db *DB) WithContext(ctx context.Context) *DB {
+	return &DB{pool: db.pool, ctx: ctx}
+}
+
+// context returns the context statements run within
+func (db *DB) context() context.Context {
+	if db.ctx == nil {
+		return context.Background()
+	}
+	return db.ctx
 }
 
 // Close closes the database connection
@@ -78,6 +128,12 @@ func (db *DB) Close() error {
 	return nil
 }
 
+// Checkpoint copies the write-ahead log into the database file and truncates it
+func (db *DB) Checkpoint() error {
+	_, err := db.conn.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+	return err
+}
+
 // Ping verifies the database connection is alive
 func (db *DB) Ping() error {
 	if db.conn == nil {
@@ -86,20 +142,101 @@ func (db *DB) Ping() error {
 	return db.conn.Ping()
 }
 
+// PingContext verifies the database connection is alive, giving up when ctx is done
+func (db *DB) PingContext(ctx context.Context) error {
+	if db.conn == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	return db.conn.PingContext(ctx)
+}
+
+// Stats returns connection pool statistics
+func (db *DB) Stats() sql.DBStats {
+	if db.conn == nil {
+		return sql.DBStats{}
+	}
+	return db.conn.Stats()
+}
+
+// Path returns the file path of the database
+func (db *DB) Path() string {
+	return db.path
+}
+
+// BackupTo writes a consistent, compacted copy of the database to destPath.
+// The destination file must not already exist.
+func (db *DB) BackupTo(destPath string) error {
+	_, err := db.Exec("VACUUM INTO ?", destPath)
+	return err
+}
+
+// IntegrityCheck runs PRAGMA integrity_check and returns the reported problems.
+// An empty slice means the database passed the check.
+func (db *DB) IntegrityCheck() ([]string, error) {
+	rows, err := db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return nil, err
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	return problems, rows.Err()
+}
+
 // Exec executes a query without returning any rows
 func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
 	if db.conn == nil {
 		return nil, fmt.Errorf("database connection is nil")
 	}
-	return db.conn.Exec(query, args...)
+	ctx, end := db.startStatement("exec", query)
+	result, err := db.conn.ExecContext(ctx, query, args...)
+	end(err)
+	return result, err
 }
 
-// Query executes a query that returns rows
-func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+// Rows is the result of a query. Its statement is timed and traced until the
+// rows are closed, so reading them counts towards the statement's duration.
+type Rows struct {
+	*sql.Rows
+	end  func(error)
+	once sync.Once
+}
+
+// Close closes the rows and ends the statement, recording any error met
+// while reading them
+func (r *Rows) Close() error {
+	err := r.Rows.Close()
+	r.once.Do(func() {
+		if readErr := r.Rows.Err(); readErr != nil {
+			r.end(readErr)
+			return
+		}
+		r.end(err)
+	})
+	return err
+}
+
+// Query executes a query that returns rows. The rows must be closed.
+func (db *DB) Query(query string, args ...interface{}) (*Rows, error) {
 	if db.conn == nil {
 		return nil, fmt.Errorf("database connection is nil")
 	}
-	return db.conn.Query(query, args...)
+	ctx, end := db.startStatement("query", query)
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		end(err)
+		return nil, err
+	}
+	return &Rows{Rows: rows, end: end}, nil
 }
 
 // QueryRow executes a query that is expected to return at most one row
@@ -108,13 +245,26 @@ func (db *DB) QueryRow(query string, args ...interface{}) *sql.Row {
 		// Return a row that will return an error when scanned
 		return &sql.Row{}
 	}
-	return db.conn.QueryRow(query, args...)
+	ctx, end := db.startStatement("query_row", query)
+	row := db.conn.QueryRowContext(ctx, query, args...)
+	end(row.Err())
+	return row
 }
 
 // CreateTable creates a table with the given schema
 func (db *DB) CreateTable(tableName string, schema string) error {
 	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", tableName, schema)
 	_, err := db.Exec(query)
+	db.InvalidateSchema(tableName)
+	return err
+}
+
+// CreateStrictTable creates a SQLite STRICT table, which rejects values that
+// don't match the declared column types
+func (db *DB) CreateStrictTable(tableName string, schema string) error {
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s) STRICT", tableName, schema)
+	_, err := db.Exec(query)
+	db.InvalidateSchema(tableName)
 	return err
 }
 
@@ -122,6 +272,7 @@ func (db *DB) CreateTable(tableName string, schema string) error {
 func (db *DB) DropTable(tableName string) error {
 	query := fmt.Sprintf("DROP TABLE IF EXISTS %s", tableName)
 	_, err := db.Exec(query)
+	db.InvalidateSchema(tableName)
 	return err
 }
 
@@ -232,7 +383,7 @@ func (db *DB) Delete(tableName string, where string, whereArgs ...interface{}) (
 }
 
 // Select performs a SELECT query and returns the results
-func (db *DB) Select(tableName string, columns []string, where string, whereArgs ...interface{}) (*sql.Rows, error) {
+func (db *DB) Select(tableName string, columns []string, where string, whereArgs ...interface{}) (*Rows, error) {
 	columnStr := "*"
 	if len(columns) > 0 {
 		columnStr = strings.Join(columns, ", ")
@@ -263,12 +414,13 @@ func (db *DB) Prepare(query string) (*sql.Stmt, error) {
 	if db.conn == nil {
 		return nil, fmt.Errorf("database connection is nil")
 	}
-	return db.conn.Prepare(query)
+	return db.conn.PrepareContext(db.context(), query)
 }
 
 // Transaction represents a database transaction
 type Transaction struct {
-	tx *sql.Tx
+	tx   *sql.Tx
+	pool *pool
 }
 
 // Begin starts a new transaction
@@ -276,11 +428,11 @@ func (db *DB) Begin() (*Transaction, error) {
 	if db.conn == nil {
 		return nil, fmt.Errorf("database connection is nil")
 	}
-	tx, err := db.conn.Begin()
+	tx, err := db.conn.BeginTx(db.context(), nil)
 	if err != nil {
 		return nil, err
 	}
-	return &Transaction{tx: tx}, nil
+	return &Transaction{tx: tx, pool: db.pool}, nil
 }
 
 // Commit commits the transaction
@@ -288,7 +440,9 @@ func (t *Transaction) Commit() error {
 	if t.tx == nil {
 		return fmt.Errorf("transaction is nil")
 	}
-	return t.tx.Commit()
+	err := t.tx.Commit()
+	t.pool.changes.Add(1)
+	return err
 }
 
 // Rollback rolls back the transaction
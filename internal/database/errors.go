@@ -0,0 +1,113 @@
+package database
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/mattn/go-sqlite3"
+)
+
+// busyRetryAfter is the Retry-After hint sent when the database is busy
+const busyRetryAfter = time.Second
+
+// QueryError converts an error from a database operation into an HTTP error.
+// SQLite errors clients can act on become typed errors with a specific error
+// code; anything else is a 500. message describes the failed operation, e.g.
+// "Failed to insert record".
+func QueryError(message string, err error) error {
+	message += ": " + err.Error()
+
+	if errors.Is(err, ErrNoSuchTable) {
+		return server.NotFound(message).WithCode(server.ErrCodeTableNotFound)
+	}
+
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return server.InternalServerError(message)
+	}
+
+	switch sqliteErr.Code {
+	case sqlite3.ErrConstraint:
+		return constraintError(message, sqliteErr)
+	case sqlite3.ErrBusy, sqlite3.ErrLocked:
+		return server.NewHTTPError(http.StatusServiceUnavailable, message).
+			WithCode(server.ErrCodeDatabaseBusy).
+			WithHeader("Retry-After", strconv.Itoa(int(busyRetryAfter.Seconds())))
+	case sqlite3.ErrMismatch:
+		return server.BadRequest(message).WithCode(server.ErrCodeDatatypeMismatch)
+	case sqlite3.ErrTooBig:
+		return server.NewHTTPError(http.StatusRequestEntityTooLarge, message).WithCode(server.ErrCodeValueTooLarge)
+	case sqlite3.ErrFull:
+		return server.NewHTTPError(http.StatusInsufficientStorage, message).WithCode(server.ErrCodeDatabaseFull)
+	case sqlite3.ErrError:
+		// Generic SQL errors are told apart by their message
+		detail := sqliteErr.Error()
+		switch {
+		case strings.HasPrefix(detail, "no such table: "):
+			table := strings.TrimPrefix(strings.TrimPrefix(detail, "no such table: "), "main.")
+			return server.NotFound(message).WithCode(server.ErrCodeTableNotFound).WithDetail("table", table)
+		case strings.HasPrefix(detail, "no such column: "):
+			column := strings.TrimPrefix(detail, "no such column: ")
+			return server.BadRequest(message).WithCode(server.ErrCodeColumnNotFound).WithDetail("column", column)
+		case strings.HasSuffix(detail, " already exists"):
+			return server.NewHTTPError(http.StatusConflict, message).WithCode(server.ErrCodeAlreadyExists)
+		case strings.Contains(detail, "syntax error"),
+			strings.HasPrefix(detail, "incomplete input"),
+			strings.HasPrefix(detail, "unrecognized token"):
+			return server.BadRequest(message).WithCode(server.ErrCodeSyntaxError)
+		}
+	}
+	return server.InternalServerError(message)
+}
+
+// constraintError builds the 409 for a constraint violation. SQLite names the
+// violated columns as "table.column" after "constraint failed: ", or the check
+// constraint's name or expression for CHECK constraints.
+func constraintError(message string, sqliteErr sqlite3.Error) server.HTTPError {
+	httpErr := server.NewHTTPError(http.StatusConflict, message)
+
+	var constraint string
+	switch sqliteErr.ExtendedCode {
+	case sqlite3.ErrConstraintUnique:
+		constraint, httpErr = "unique", httpErr.WithCode(server.ErrCodeUniqueViolation)
+	case sqlite3.ErrConstraintPrimaryKey, sqlite3.ErrConstraintRowID:
+		constraint, httpErr = "primary_key", httpErr.WithCode(server.ErrCodePrimaryKeyViolation)
+	case sqlite3.ErrConstraintNotNull:
+		constraint, httpErr = "not_null", httpErr.WithCode(server.ErrCodeNotNullViolation)
+	case sqlite3.ErrConstraintForeignKey:
+		constraint, httpErr = "foreign_key", httpErr.WithCode(server.ErrCodeForeignKeyViolation)
+	case sqlite3.ErrConstraintCheck:
+		constraint, httpErr = "check", httpErr.WithCode(server.ErrCodeCheckViolation)
+	default:
+		return httpErr.WithCode(server.ErrCodeConstraintViolation)
+	}
+	httpErr = httpErr.WithDetail("constraint", constraint)
+
+	_, target, found := strings.Cut(sqliteErr.Error(), "constraint failed: ")
+	if !found || target == "" {
+		return httpErr
+	}
+	if constraint == "check" {
+		return httpErr.WithDetail("name", target)
+	}
+
+	// Composite keys list every column: "t.a, t.b"
+	var table string
+	var columns []string
+	for _, qualified := range strings.Split(target, ", ") {
+		tableName, column, ok := strings.Cut(qualified, ".")
+		if !ok {
+			tableName, column = "", qualified
+		}
+		table = tableName
+		columns = append(columns, column)
+	}
+	if table != "" {
+		httpErr = httpErr.WithDetail("table", table)
+	}
+	return httpErr.WithDetail("column", strings.Join(columns, ","))
+}
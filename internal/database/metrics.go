@@ -0,0 +1,135 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"sync"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/metrics"
+)
+
+// queryDuration records how long statements take to execute, by operation:
+// "exec", "query" (until the first row is available) or "query_row"
+var queryDuration = metrics.NewHistogramVec("pebbledb_db_query_duration_seconds",
+	"Time taken by database statements in seconds, by operation.",
+	metrics.DefBuckets, "operation")
+
+// Connection pool metrics, read from sql.DBStats of every pooled project database
+func init() {
+	metrics.NewGaugeFunc("pebbledb_project_databases_open",
+		"Project databases currently held open in the connection pool.",
+		func() []metrics.Sample {
+			projectDBs.RLock()
+			defer projectDBs.RUnlock()
+			return metrics.Value(float64(len(projectDBs.conns)))
+		})
+
+	// Pool statistics are summed over all project databases. Labelling them by
+	// project would publish every user and project ID on the unauthenticated
+	// /metrics endpoint, with one series per tenant, so the per-project families
+	// are only registered by EnablePerProjectPoolMetrics.
+	for _, stat := range poolStatFields {
+		register := metrics.NewGaugeFunc
+		if stat.counter {
+			register = metrics.NewCounterFunc
+		}
+		register("pebbledb_db_pool_"+stat.name, stat.help, func() []metrics.Sample {
+			return metrics.Value(stat.value(poolStats(stat.counter)))
+		})
+	}
+}
+
+// poolStat describes one sql.DBStats field exported as a pool metric
+type poolStat struct {
+	name    string
+	help    string
+	counter bool
+	value   func(sql.DBStats) float64
+}
+
+var poolStatFields = []poolStat{
+	{"max_open_connections", "Maximum number of open connections to project databases.", false,
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }},
+	{"open_connections", "Established connections to project databases, in use or idle.", false,
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) }},
+	{"in_use_connections", "Connections to project databases currently in use.", false,
+		func(s sql.DBStats) float64 { return float64(s.InUse) }},
+	{"idle_connections", "Idle connections to project databases.", false,
+		func(s sql.DBStats) float64 { return float64(s.Idle) }},
+	{"wait_count_total", "Connections waited for because a pool was exhausted.", true,
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) }},
+	{"wait_duration_seconds_total", "Total time blocked waiting for a new connection, in seconds.", true,
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+	{"max_idle_closed_total", "Connections closed due to the idle connection limit.", true,
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }},
+	{"max_idle_time_closed_total", "Connections closed due to the idle time limit.", true,
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }},
+	{"max_lifetime_closed_total", "Connections closed due to the connection lifetime limit.", true,
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }},
+}
+
+var perProjectPoolMetrics sync.Once
+
+// EnablePerProjectPoolMetrics additionally exports the statistics of each pooled
+// project database as pebbledb_project_db_pool_* series labelled by user and
+// project. Series disappear when their database leaves the pool.
+func EnablePerProjectPoolMetrics() {
+	perProjectPoolMetrics.Do(func() {
+		for _, stat := range poolStatFields {
+			register := metrics.NewGaugeFunc
+			if stat.counter {
+				register = metrics.NewCounterFunc
+			}
+			register("pebbledb_project_db_pool_"+stat.name, stat.help, func() []metrics.Sample {
+				return projectPoolSamples(stat.value)
+			}, "user", "project")
+		}
+	})
+}
+
+// projectPoolSamples reports value for every pooled project database
+func projectPoolSamples(value func(sql.DBStats) float64) []metrics.Sample {
+	projectDBs.RLock()
+	defer projectDBs.RUnlock()
+
+	samples := make([]metrics.Sample, 0, len(projectDBs.conns))
+	for key, db := range projectDBs.conns {
+		userID, projectID, _ := strings.Cut(key, "/")
+		samples = append(samples, metrics.Sample{
+			LabelValues: []string{userID, projectID},
+			Value:       value(db.Stats()),
+		})
+	}
+	return samples
+}
+
+// poolStats sums the statistics of the pooled project databases. With
+// withClosed, the counters of databases closed since startup are included,
+// so counters never decrease when a database leaves the pool.
+func poolStats(withClosed bool) sql.DBStats {
+	projectDBs.RLock()
+	defer projectDBs.RUnlock()
+
+	var total sql.DBStats
+	if withClosed {
+		total = projectDBs.closed
+	}
+	for _, db := range projectDBs.conns {
+		s := db.Stats()
+		total.MaxOpenConnections += s.MaxOpenConnections
+		total.OpenConnections += s.OpenConnections
+		total.InUse += s.InUse
+		total.Idle += s.Idle
+		addPoolCounters(&total, s)
+	}
+	return total
+}
+
+// addPoolCounters adds the cumulative fields of s to total
+func addPoolCounters(total *sql.DBStats, s sql.DBStats) {
+	total.WaitCount += s.WaitCount
+	total.WaitDuration += s.WaitDuration
+	total.MaxIdleClosed += s.MaxIdleClosed
+	total.MaxIdleTimeClosed += s.MaxIdleTimeClosed
+	total.MaxLifetimeClosed += s.MaxLifetimeClosed
+}
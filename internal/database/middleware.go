@@ -4,46 +4,36 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"log"
 	"net/http"
+	"os"
 	"path/filepath"
-	"strings"
 
+	"github.com/ArnavChoudhary9/PebbleDB/internal/logging"
 	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
 	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
 )
 
-// Actions that don't require database middleware
-var skipDBActions = map[string]bool{
-	"create_project": true,
-	"list_projects":  true,
-	"delete_project": true,
-	"get_project":    true,
-}
-
-// Middleware creates a middleware that injects database connections into the request context
-func Middleware() func(server.HTTPHandlerFunc) server.HTTPHandlerFunc {
+// Middleware creates a middleware that injects database connections into the
+// request context. JSON requests whose action needsDB reports false are passed
+// through without one.
+func Middleware(needsDB func(action string) bool) func(server.HTTPHandlerFunc) server.HTTPHandlerFunc {
 	return func(next server.HTTPHandlerFunc) server.HTTPHandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) error {
 			// Parse JSON to check if we should skip DB middleware
 			var req types.JSONRequest
 			if r.Method == "POST" && r.Body != nil && r.ContentLength > 0 {
-				// Read the body
-				bodyBytes, err := io.ReadAll(r.Body)
+				// Read the body, leaving a copy for downstream handlers
+				bodyBytes, err := server.PeekBody(r)
 				if err != nil {
-					return server.BadRequest("Failed to read request body")
+					return server.BodyReadError(err)
 				}
 
 				// Try to parse JSON, but don't fail if it's invalid
 				json.Unmarshal(bodyBytes, &req)
-
-				// Reset body for downstream handlers
-				r.Body = io.NopCloser(strings.NewReader(string(bodyBytes)))
 			}
 
-			// Skip database middleware for certain actions
-			if skipDBActions[req.Action] {
+			// Skip database middleware for actions that don't use one
+			if !needsDB(req.Action) {
 				return next(w, r)
 			}
 
@@ -53,6 +43,9 @@ func Middleware() func(server.HTTPHandlerFunc) server.HTTPHandlerFunc {
 			}
 
 			projectID := req.ProjectID
+			if projectID == "" {
+				projectID = server.Param(r, "project")
+			}
 			if projectID == "" {
 				projectID = r.URL.Query().Get("project")
 			}
@@ -60,6 +53,9 @@ func Middleware() func(server.HTTPHandlerFunc) server.HTTPHandlerFunc {
 			if projectID == "" {
 				return server.BadRequest("Missing project ID")
 			}
+			if !ValidProjectID(projectID) {
+				return server.BadRequest("Invalid project ID")
+			}
 
 			// Get working directory from context
 			basePath, ok := r.Context().Value(types.WorkingDirectoryContextKey).(string)
@@ -71,18 +67,32 @@ func Middleware() func(server.HTTPHandlerFunc) server.HTTPHandlerFunc {
 			dbKey := fmt.Sprintf("%s/%s", userID, projectID)
 			projectsBasePath := filepath.Join(basePath, "projects")
 
-			log.Printf("Establishing database connection for project: %s (user: %s)", projectID, userID)
-			db, err := GetProjectDB(projectsBasePath, dbKey)
+			// Only open databases of existing projects, so lookups don't create files
+			if info, err := os.Stat(filepath.Join(projectsBasePath, userID, projectID)); err != nil || !info.IsDir() {
+				return server.NotFound("Project not found")
+			}
+
+			logging.AddFields(r.Context(), "project", projectID)
+			logging.FromContext(r.Context()).Debug("Opening project database", "key", dbKey)
+			db, err := GetProjectDB(r.Context(), projectsBasePath, dbKey)
 			if err != nil {
 				return server.InternalServerError("Failed to load database: " + err.Error())
 			}
 
-			ctx := context.WithValue(r.Context(), types.DatabaseContextKey, db)
+			// Statements run within the request so they are traced and cancelled with it
+			ctx := context.WithValue(r.Context(), types.DatabaseContextKey, db.WithContext(r.Context()))
 			return next(w, r.WithContext(ctx))
 		}
 	}
 }
 
+// ValidProjectID reports whether id can name a project: a single path element
+// that is not "." or "..". Path parameters are decoded, so "a%2F..%2Fb" arrives
+// as "a/../b" and must be rejected here.
+func ValidProjectID(id string) bool {
+	return id != "" && id != "." && id != ".." && filepath.Base(id) == id
+}
+
 // GetDBFromContext retrieves the database connection from the request context
 func GetDBFromContext(r *http.Request) *DB {
 	db, ok := r.Context().Value(types.DatabaseContextKey).(*DB)
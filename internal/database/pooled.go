@@ -1,25 +1,56 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/tracing"
 )
 
 var projectDBs = struct {
 	sync.RWMutex
-	conns map[string]*DB
+	conns  map[string]*DB
+	closed sql.DBStats // Counters of databases closed since startup, kept for metrics
 }{conns: make(map[string]*DB)}
 
+// projectDBConfig holds the settings used to open project databases; Path is set per project
+var projectDBConfig = Config{
+	MaxOpenConns:    10,
+	MaxIdleConns:    5,
+	ConnMaxLifetime: time.Hour,
+	WALMode:         true,
+	ForeignKeys:     true,
+}
+
+// SetProjectDBConfig sets the connection settings for project databases opened
+// afterwards. It must be called before the server starts handling requests.
+func SetProjectDBConfig(cfg Config) {
+	projectDBs.Lock()
+	defer projectDBs.Unlock()
+	projectDBConfig = cfg
+}
+
 // GetProjectDB returns a database connection for a specific project
 // It uses connection pooling to reuse existing connections
-func GetProjectDB(basePath, key string) (*DB, error) {
+func GetProjectDB(ctx context.Context, basePath, key string) (_ *DB, err error) {
+	_, span := tracing.Start(ctx, "database.GetProjectDB", tracing.KindInternal, "project.key", key)
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
 	projectDBs.RLock()
 	if db, ok := projectDBs.conns[key]; ok {
 		projectDBs.RUnlock()
+		span.SetAttributes("pool.hit", true)
 		return db, nil
 	}
 	projectDBs.RUnlock()
+	span.SetAttributes("pool.hit", false)
 
 	projectDBs.Lock()
 	defer projectDBs.Unlock()
@@ -29,15 +60,8 @@ func GetProjectDB(basePath, key string) (*DB, error) {
 		return db, nil
 	}
 
-	dbPath := fmt.Sprintf("%s/%s.db", basePath, key)
-	cfg := Config{
-		Path:            dbPath,
-		MaxOpenConns:    10,
-		MaxIdleConns:    5,
-		ConnMaxLifetime: time.Hour,
-		WALMode:         true,
-		ForeignKeys:     true,
-	}
+	cfg := projectDBConfig
+	cfg.Path = fmt.Sprintf("%s/%s.db", basePath, key)
 
 	db, err := NewDB(cfg)
 	if err != nil {
@@ -48,30 +72,65 @@ func GetProjectDB(basePath, key string) (*DB, error) {
 	return db, nil
 }
 
-// CloseProjectDB closes a specific project database connection
+// PingProjectDBs pings up to sample pooled project databases, an arbitrary
+// subset of the pool, and returns how many were pinged and the first failure
+func PingProjectDBs(ctx context.Context, sample int) (int, error) {
+	projectDBs.RLock()
+	dbs := make(map[string]*DB, sample)
+	for key, db := range projectDBs.conns {
+		if len(dbs) == sample {
+			break
+		}
+		dbs[key] = db
+	}
+	projectDBs.RUnlock()
+
+	for key, db := range dbs {
+		if err := db.PingContext(ctx); err != nil {
+			return len(dbs), fmt.Errorf("ping %s: %w", key, err)
+		}
+	}
+	return len(dbs), nil
+}
+
+// CloseProjectDB checkpoints and closes a specific project database connection
 func CloseProjectDB(key string) error {
 	projectDBs.Lock()
 	defer projectDBs.Unlock()
 
 	if db, ok := projectDBs.conns[key]; ok {
-		err := db.Close()
 		delete(projectDBs.conns, key)
-		return err
+		return closePooledDB(key, db)
 	}
 	return nil
 }
 
-// CloseAllProjectDBs closes all project database connections
+// CloseAllProjectDBs checkpoints and closes all project database connections,
+// returning every error encountered
 func CloseAllProjectDBs() error {
 	projectDBs.Lock()
 	defer projectDBs.Unlock()
 
-	var lastErr error
+	var errs []error
 	for key, db := range projectDBs.conns {
-		if err := db.Close(); err != nil {
-			lastErr = err
+		if err := closePooledDB(key, db); err != nil {
+			errs = append(errs, err)
 		}
 		delete(projectDBs.conns, key)
 	}
-	return lastErr
+	return errors.Join(errs...)
+}
+
+// closePooledDB checkpoints the WAL so the database file is self-contained, then closes it.
+// The caller holds the pool lock.
+func closePooledDB(key string, db *DB) error {
+	var errs []error
+	if err := db.Checkpoint(); err != nil {
+		errs = append(errs, fmt.Errorf("checkpoint %s: %w", key, err))
+	}
+	if err := db.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close %s: %w", key, err))
+	}
+	addPoolCounters(&projectDBs.closed, db.Stats())
+	return errors.Join(errs...)
 }
@@ -0,0 +1,381 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoSuchTable is returned when a table's columns are requested for a missing table
+var ErrNoSuchTable = errors.New("no such table")
+
+// Column kinds, derived from a column's declared type
+const (
+	KindAny      = "any"
+	KindInteger  = "integer"
+	KindReal     = "real"
+	KindText     = "text"
+	KindBlob     = "blob"
+	KindBoolean  = "boolean"
+	KindDatetime = "datetime"
+)
+
+// dateTimeFormats are the accepted input formats for datetime columns
+var dateTimeFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ColumnInfo describes a table column as reported by PRAGMA table_info
+type ColumnInfo struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Kind       string `json:"kind"`
+	NotNull    bool   `json:"not_null"`
+	HasDefault bool   `json:"has_default"`
+	PrimaryKey bool   `json:"primary_key"`
+}
+
+// FieldError describes an invalid value for a single column
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every field that failed strict-write validation
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ColumnKind maps a declared column type to a kind, following SQLite's type
+// affinity rules with BOOLEAN and date/time types split out
+func ColumnKind(declared string) string {
+	declared = strings.ToUpper(declared)
+	switch {
+	case declared == "":
+		return KindAny
+	case strings.Contains(declared, "BOOL"):
+		return KindBoolean
+	case declared == "DATE" || declared == "DATETIME" || declared == "TIMESTAMP":
+		return KindDatetime
+	case strings.Contains(declared, "INT"):
+		return KindInteger
+	case strings.Contains(declared, "CHAR"), strings.Contains(declared, "CLOB"), strings.Contains(declared, "TEXT"):
+		return KindText
+	case strings.Contains(declared, "BLOB"):
+		return KindBlob
+	case strings.Contains(declared, "REAL"), strings.Contains(declared, "FLOA"), strings.Contains(declared, "DOUB"):
+		return KindReal
+	default:
+		return KindAny
+	}
+}
+
+// TableColumns returns the columns of a table. Results are cached per table
+// until the schema changes, whether through this DB or any other statement
+// or connection, which SQLite records in PRAGMA schema_version.
+func (db *DB) TableColumns(tableName string) ([]ColumnInfo, error) {
+	var version int64
+	if err := db.QueryRow("PRAGMA schema_version").Scan(&version); err != nil {
+		return nil, err
+	}
+
+	db.schemaMu.Lock()
+	if version != db.schemaVersion {
+		db.schemaCache = make(map[string][]ColumnInfo)
+		db.schemaVersion = version
+	}
+	columns, ok := db.schemaCache[tableName]
+	db.schemaMu.Unlock()
+	if ok {
+		return columns, nil
+	}
+
+	rows, err := db.Query("SELECT name, type, \"notnull\", dflt_value, pk FROM pragma_table_info(?)", tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var col ColumnInfo
+		var dflt sql.NullString
+		var pk int
+		if err := rows.Scan(&col.Name, &col.Type, &col.NotNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		col.Kind = ColumnKind(col.Type)
+		col.HasDefault = dflt.Valid
+		col.PrimaryKey = pk > 0
+		columns = append(columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoSuchTable, tableName)
+	}
+
+	db.schemaMu.Lock()
+	if version == db.schemaVersion {
+		db.schemaCache[tableName] = columns
+	}
+	db.schemaMu.Unlock()
+	return columns, nil
+}
+
+// ForeignKey describes a (possibly composite) foreign key of a table
+type ForeignKey struct {
+	Table    string   `json:"table"`     // Table holding the foreign key
+	From     []string `json:"from"`      // Referencing columns in Table
+	RefTable string   `json:"ref_table"` // Referenced table
+	To       []string `json:"to"`        // Referenced columns in RefTable
+}
+
+// ForeignKeys returns the foreign keys declared on a table. References that
+// omit the column list are resolved to the referenced table's primary key.
+func (db *DB) ForeignKeys(tableName string) ([]ForeignKey, error) {
+	rows, err := db.Query("SELECT id, \"table\", \"from\", \"to\" FROM pragma_foreign_key_list(?) ORDER BY id, seq", tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []ForeignKey
+	lastID := -1
+	for rows.Next() {
+		var id int
+		var refTable, from string
+		var to sql.NullString
+		if err := rows.Scan(&id, &refTable, &from, &to); err != nil {
+			return nil, err
+		}
+		if id != lastID {
+			keys = append(keys, ForeignKey{Table: tableName, RefTable: refTable})
+			lastID = id
+		}
+		key := &keys[len(keys)-1]
+		key.From = append(key.From, from)
+		key.To = append(key.To, to.String)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range keys {
+		if keys[i].To[0] != "" {
+			continue
+		}
+		refColumns, err := db.TableColumns(keys[i].RefTable)
+		if err != nil {
+			return nil, err
+		}
+		keys[i].To = keys[i].To[:0]
+		for _, col := range refColumns {
+			if col.PrimaryKey {
+				keys[i].To = append(keys[i].To, col.Name)
+			}
+		}
+	}
+
+	return keys, nil
+}
+
+// HasRowid reports whether a table has a rowid, that is whether it was not
+// created WITHOUT ROWID
+func (db *DB) HasRowid(tableName string) (bool, error) {
+	var withoutRowid bool
+	err := db.QueryRow("SELECT wr FROM pragma_table_list(?)", tableName).Scan(&withoutRowid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("%w: %s", ErrNoSuchTable, tableName)
+	}
+	return !withoutRowid, err
+}
+
+// InvalidateSchema drops the cached columns of a table, e.g. after ALTER TABLE
+func (db *DB) InvalidateSchema(tableName string) {
+	db.schemaMu.Lock()
+	delete(db.schemaCache, tableName)
+	db.schemaMu.Unlock()
+}
+
+// CoerceRow converts JSON-decoded values to the declared types of the table's
+// columns. Unknown columns and values that cannot be converted are reported
+// together in a *ValidationError.
+func (db *DB) CoerceRow(tableName string, data map[string]interface{}) (map[string]interface{}, error) {
+	columns, err := db.TableColumns(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]ColumnInfo, len(columns))
+	for _, col := range columns {
+		byName[strings.ToLower(col.Name)] = col
+	}
+
+	result := make(map[string]interface{}, len(data))
+	var fieldErrs []FieldError
+	for field, value := range data {
+		col, ok := byName[strings.ToLower(field)]
+		if !ok {
+			fieldErrs = append(fieldErrs, FieldError{Field: field, Message: "unknown column"})
+			continue
+		}
+
+		coerced, err := coerceValue(col, value)
+		if err != nil {
+			fieldErrs = append(fieldErrs, FieldError{Field: field, Message: err.Error()})
+			continue
+		}
+		result[col.Name] = coerced
+	}
+
+	if len(fieldErrs) > 0 {
+		sort.Slice(fieldErrs, func(i, j int) bool { return fieldErrs[i].Field < fieldErrs[j].Field })
+		return nil, &ValidationError{Fields: fieldErrs}
+	}
+	return result, nil
+}
+
+// coerceValue converts a single JSON-decoded value to the column's kind
+func coerceValue(col ColumnInfo, value interface{}) (interface{}, error) {
+	if value == nil {
+		// INTEGER PRIMARY KEY columns are assigned a rowid when NULL
+		if col.NotNull && !col.PrimaryKey {
+			return nil, fmt.Errorf("must not be null")
+		}
+		return nil, nil
+	}
+
+	switch col.Kind {
+	case KindInteger:
+		return coerceInteger(value)
+	case KindReal:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		}
+		return nil, fmt.Errorf("expected a real number, got %s", describeValue(value))
+	case KindText:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+		return nil, fmt.Errorf("expected text, got %s", describeValue(value))
+	case KindBlob:
+		switch v := value.(type) {
+		case string:
+			return []byte(v), nil
+		case map[string]interface{}:
+			// Accept the same marker used when encoding BLOBs in results
+			if encoded, ok := v["base64"].(string); ok && v["$type"] == KindBlob {
+				b, err := base64.StdEncoding.DecodeString(encoded)
+				if err != nil {
+					return nil, fmt.Errorf("invalid base64 blob: %v", err)
+				}
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("expected a blob, got %s", describeValue(value))
+	case KindBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case float64:
+			if v == 0 || v == 1 {
+				return v == 1, nil
+			}
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("expected a boolean, got %s", describeValue(value))
+	case KindDatetime:
+		switch v := value.(type) {
+		case string:
+			for _, layout := range dateTimeFormats {
+				if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+					return t.UTC(), nil
+				}
+			}
+		case float64:
+			// Numbers are treated as Unix timestamps in seconds
+			sec, frac := math.Modf(v)
+			return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+		}
+		return nil, fmt.Errorf("expected a date/time, got %s", describeValue(value))
+	default:
+		switch v := value.(type) {
+		case float64:
+			if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+				return int64(v), nil
+			}
+			return v, nil
+		case bool, string:
+			return v, nil
+		}
+		return nil, fmt.Errorf("unsupported value %s", describeValue(value))
+	}
+}
+
+// coerceInteger converts numbers, booleans and numeric strings to int64
+func coerceInteger(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+			return int64(v), nil
+		}
+	case bool:
+		if v {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case string:
+		if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+			return n, nil
+		}
+	}
+	return nil, fmt.Errorf("expected an integer, got %s", describeValue(value))
+}
+
+// describeValue renders a value for validation messages
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/tracing"
+)
+
+// startStatement starts timing a statement and tracing it as a span, named
+// after its SQL keyword, within the view's context. The returned function
+// records the statement's outcome and counts it as a change if it may write.
+func (db *DB) startStatement(operation, query string) (context.Context, func(error)) {
+	start := time.Now()
+	keyword, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	keyword = strings.ToUpper(keyword)
+
+	ctx, span := tracing.Start(db.context(), keyword, tracing.KindClient,
+		"db.system", "sqlite",
+		"db.name", filepath.Base(db.path),
+		"db.operation", keyword,
+		"db.statement", query,
+	)
+	return ctx, func(err error) {
+		queryDuration.ObserveSince(start, operation)
+		if mayWrite(keyword, query) {
+			// Even failed statements count, since a multi-statement query
+			// may have written before failing
+			db.changes.Add(1)
+		}
+		span.RecordError(err)
+		span.End()
+	}
+}
+
+// mayWrite reports whether a statement starting with keyword may change data.
+// Only plain SELECT and EXPLAIN statements and PRAGMA queries are known not to.
+func mayWrite(keyword, query string) bool {
+	switch keyword {
+	case "SELECT", "EXPLAIN":
+		return false
+	case "PRAGMA":
+		return strings.Contains(query, "=")
+	}
+	return true
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/openapi"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
+
+// Permission is an access right an action requires
+type Permission string
+
+const (
+	PermissionRead     Permission = "read"     // Read projects, schemas and rows
+	PermissionWrite    Permission = "write"    // Insert, update and delete rows
+	PermissionSchema   Permission = "schema"   // Create and drop tables
+	PermissionProjects Permission = "projects" // Create and delete projects
+)
+
+// ActionHandler runs an action. db is the project database for actions that
+// need one, and nil otherwise.
+type ActionHandler func(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error
+
+// Action is an operation served by the /api/db endpoint, selected by the
+// request's action field
+type Action struct {
+	Name string
+	// NeedsDB opens the project database named by project_id before the
+	// handler runs; without it project_id is optional
+	NeedsDB bool
+	// Permissions the caller must hold, checked by the ActionAuthorizer. They
+	// also choose the rate limit class: projects and schema count as DDL,
+	// write as writes and anything else as reads.
+	Permissions []Permission
+	// Validate checks the request and may normalize it before the handler
+	// runs. It is optional.
+	Validate func(req *types.JSONRequest) error
+	Handler  ActionHandler
+	Doc      ActionDoc
+}
+
+// ActionDoc describes an action in the OpenAPI document
+type ActionDoc struct {
+	Summary  string
+	Fields   []string        // JSONRequest fields the action reads, besides action
+	Required []string        // Fields that must be set
+	Data     *openapi.Schema // The data field of a successful response
+}
+
+// ActionAuthorizer decides whether a request may run an action, typically by
+// comparing the action's Permissions with the caller's. It returns an error,
+// such as server.Forbidden, to refuse.
+type ActionAuthorizer func(r *http.Request, action *Action) error
+
+var (
+	// actions holds the registered actions by name
+	actions = map[string]*Action{}
+
+	// actionAuthorizer checks permissions; nil allows every action, as each
+	// user only reaches their own projects
+	actionAuthorizer ActionAuthorizer
+
+	// actionsSealed is set by SetupRoutes, after which the registry is read-only
+	actionsSealed bool
+)
+
+func init() {
+	for _, action := range builtinActions() {
+		if err := RegisterAction(action); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterAction adds an action to the /api/db endpoint. Actions must be
+// registered before SetupRoutes, so they are part of the OpenAPI document,
+// and names must be unique.
+func RegisterAction(action Action) error {
+	switch {
+	case actionsSealed:
+		return fmt.Errorf("action %s registered after SetupRoutes", action.Name)
+	case action.Name == "":
+		return errors.New("action name is required")
+	case action.Handler == nil:
+		return fmt.Errorf("action %s has no handler", action.Name)
+	case action.Doc.Summary == "" || action.Doc.Data == nil:
+		return fmt.Errorf("action %s needs a summary and a response schema", action.Name)
+	}
+	if _, exists := actions[action.Name]; exists {
+		return fmt.Errorf("action %s is already registered", action.Name)
+	}
+	actions[action.Name] = &action
+	return nil
+}
+
+// SetActionAuthorizer installs the permission check run before every action.
+// Like RegisterAction it must be called before SetupRoutes.
+func SetActionAuthorizer(authorizer ActionAuthorizer) {
+	if actionsSealed {
+		panic("action authorizer set after SetupRoutes")
+	}
+	actionAuthorizer = authorizer
+}
+
+// sealActions freezes the registry before requests are served
+func sealActions() {
+	actionsSealed = true
+}
+
+// actionNames returns the registered actions in order
+func actionNames() []string {
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// actionNeedsDB reports whether the named action needs the project database.
+// Unknown actions do, so they fail the same way whatever their name.
+func actionNeedsDB(name string) bool {
+	action, ok := actions[name]
+	return !ok || action.NeedsDB
+}
+
+// rateClass returns the rate limit class of the action's permissions
+func (a *Action) rateClass() server.RateClass {
+	class := server.RateRead
+	for _, permission := range a.Permissions {
+		switch permission {
+		case PermissionSchema, PermissionProjects:
+			return server.RateDDL
+		case PermissionWrite:
+			class = server.RateWrite
+		}
+	}
+	return class
+}
+
+// run authorizes, validates and handles a request for the action
+func (a *Action) run(w http.ResponseWriter, r *http.Request, req types.JSONRequest) error {
+	if actionAuthorizer != nil {
+		if err := actionAuthorizer(r, a); err != nil {
+			return err
+		}
+	}
+	if a.Validate != nil {
+		if err := a.Validate(&req); err != nil {
+			return err
+		}
+	}
+
+	var db *database.DB
+	if a.NeedsDB {
+		if db = database.GetDBFromContext(r); db == nil {
+			return server.InternalServerError("Database connection not available")
+		}
+	}
+	return a.Handler(w, r, req, db)
+}
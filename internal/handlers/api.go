@@ -5,68 +5,34 @@ import (
 	"fmt"
 	"net/http"
 
-	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/logging"
 	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
 	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
 )
 
-// DatabaseHandler handles all database operations via JSON
-func DatabaseHandler(w http.ResponseWriter, r *http.Request) error {
-	var req types.JSONRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		return server.BadRequest("Invalid JSON request: " + err.Error())
+// DatabaseHandler runs the registered action named by a JSON request
+func DatabaseHandler(w http.ResponseWriter, r *http.Request) (err error) {
+	req, err := decodeJSONRequest(r)
+	if err != nil {
+		return err
 	}
 
-	// Handle project management actions first (these don't require database connection)
-	switch req.Action {
-	case "create_project":
-		return handleCreateProject(w, req, r)
-	case "list_projects":
-		return handleListProjects(w, req, r)
-	case "delete_project":
-		return handleDeleteProject(w, req, r)
-	case "get_project":
-		return handleGetProject(w, req, r)
-	case "get_tables":
-		return handleGetTables(w, req, r)
-	}
+	logging.AddFields(r.Context(), "action", req.Action)
+	defer func() { recordAction(req.Action, err) }()
 
-	// For database operations, get the database connection
-	db := database.GetDBFromContext(r)
-	if db == nil {
-		return server.InternalServerError("Database connection not available")
+	action, ok := actions[req.Action]
+	if !ok {
+		return server.BadRequest(fmt.Sprintf("Unknown action: %s", req.Action)).WithCode(server.ErrCodeUnknownAction)
 	}
 
-	switch req.Action {
-	case "create_table":
-		return handleCreateTable(w, req, db)
-	case "insert":
-		return handleInsert(w, req, db)
-	case "join":
-		return handleJoin(w, req, db)
-	case "select":
-		return handleSelect(w, req, db)
-	case "select_join":
-		return handleSelectWithJoin(w, req, db)
-	case "count_join":
-		return handleCountWithJoin(w, req, db)
-	case "query_builder":
-		return handleQueryBuilder(w, req, db)
-	case "update":
-		return handleUpdate(w, req, db)
-	case "delete":
-		return handleDelete(w, req, db)
-	case "count":
-		return handleCount(w, req, db)
-	case "drop_table":
-		return handleDropTable(w, req, db)
-	case "table_exists":
-		return handleTableExists(w, req, db)
-	case "get_schema":
-		return handleGetSchema(w, req, db)
-	default:
-		return server.BadRequest(fmt.Sprintf("Unknown action: %s", req.Action))
+	if err := resolveStreamMode(r, &req); err != nil {
+		return err
+	}
+	if err := resolveResultFormat(&req); err != nil {
+		return err
 	}
+
+	return action.run(w, r, req)
 }
 
 // Helper function to send success response
@@ -79,12 +45,8 @@ func sendSuccess(w http.ResponseWriter, data interface{}) error {
 	return json.NewEncoder(w).Encode(response)
 }
 
-// Helper function to send error response
-func sendError(w http.ResponseWriter, message string, statusCode int) error {
-	response := types.JSONResponse{
-		Success: false,
-		Error:   message,
-	}
+// Helper function to send a full response envelope with a status code
+func sendResponse(w http.ResponseWriter, statusCode int, response types.JSONResponse) error {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	return json.NewEncoder(w).Encode(response)
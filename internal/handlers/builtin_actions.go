@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/openapi"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
+
+// Sets of fields shared by several actions
+var (
+	filterFields = []string{"where", "where_args"}
+	readFields   = []string{"columns", "order_by", "limit", "offset", "stream", "result_format"}
+	groupFields  = []string{"joins", "group_by", "having"}
+)
+
+// fields concatenates field lists
+func fields(lists ...[]string) []string {
+	var all []string
+	for _, list := range lists {
+		all = append(all, list...)
+	}
+	return all
+}
+
+// Response data shapes shared by several actions
+var (
+	messageSchema = openapi.Object(map[string]*openapi.Schema{
+		"message": openapi.String(""),
+	})
+	rowsAffectedSchema = openapi.Object(map[string]*openapi.Schema{
+		"rows_affected": openapi.Integer(""),
+	})
+	countSchema = openapi.Object(map[string]*openapi.Schema{
+		"count": openapi.Integer(""),
+	})
+)
+
+// builtinActions returns the actions PebbleDB serves out of the box
+func builtinActions() []Action {
+	return []Action{
+		{
+			Name:        "create_project",
+			Permissions: []Permission{PermissionProjects},
+			Validate:    requireProjectName,
+			Handler:     handleCreateProject,
+			Doc: ActionDoc{
+				Summary:  "Create a project with an empty database",
+				Fields:   []string{"project_name", "project_description"},
+				Required: []string{"project_name"},
+				Data:     openapi.Ref("Project"),
+			},
+		},
+		{
+			Name:        "list_projects",
+			Permissions: []Permission{PermissionRead},
+			Handler:     handleListProjects,
+			Doc: ActionDoc{
+				Summary: "List the caller's projects",
+				Data:    openapi.Array(openapi.Ref("Project"), ""),
+			},
+		},
+		{
+			Name:        "get_project",
+			Permissions: []Permission{PermissionRead},
+			Validate:    requireProjectID,
+			Handler:     handleGetProject,
+			Doc: ActionDoc{
+				Summary:  "Get a project",
+				Fields:   []string{"project_id"},
+				Required: []string{"project_id"},
+				Data:     openapi.Ref("Project"),
+			},
+		},
+		{
+			Name:        "delete_project",
+			Permissions: []Permission{PermissionProjects},
+			Validate:    requireProjectID,
+			Handler:     handleDeleteProject,
+			Doc: ActionDoc{
+				Summary:  "Delete a project and its database",
+				Fields:   []string{"project_id"},
+				Required: []string{"project_id"},
+				Data:     messageSchema,
+			},
+		},
+		{
+			Name:        "get_tables",
+			NeedsDB:     true,
+			Permissions: []Permission{PermissionRead},
+			Handler:     handleGetTables,
+			Doc: ActionDoc{
+				Summary:  "List the tables of a project",
+				Fields:   []string{"project_id"},
+				Required: []string{"project_id"},
+				Data: openapi.Object(map[string]*openapi.Schema{
+					"tables": openapi.Array(openapi.String(""), ""),
+					"count":  openapi.Integer(""),
+				}),
+			},
+		},
+		{
+			Name:        "create_table",
+			NeedsDB:     true,
+			Permissions: []Permission{PermissionSchema},
+			Validate:    requireTableDefinition,
+			Handler:     handleCreateTable,
+			Doc: ActionDoc{
+				Summary:  "Create a table from column definitions, or inferred from sample data",
+				Fields:   []string{"project_id", "table", "schema", "data", "strict"},
+				Required: []string{"project_id", "table"},
+				Data:     messageSchema,
+			},
+		},
+		{
+			Name:        "drop_table",
+			NeedsDB:     true,
+			Permissions: []Permission{PermissionSchema},
+			Validate:    requireTable,
+			Handler:     handleDropTable,
+			Doc: ActionDoc{
+				Summary:  "Drop a table",
+				Fields:   []string{"project_id", "table"},
+				Required: []string{"project_id", "table"},
+				Data:     messageSchema,
+			},
+		},
+		{
+			Name:        "table_exists",
+			NeedsDB:     true,
+			Permissions: []Permission{PermissionRead},
+			Validate:    requireTable,
+			Handler:     handleTableExists,
+			Doc: ActionDoc{
+				Summary:  "Check whether a table exists",
+				Fields:   []string{"project_id", "table"},
+				Required: []string{"project_id", "table"},
+				Data: openapi.Object(map[string]*openapi.Schema{
+					"table":  openapi.String(""),
+					"exists": openapi.Boolean(""),
+				}),
+			},
+		},
+		{
+			Name:        "get_schema",
+			NeedsDB:     true,
+			Permissions: []Permission{PermissionRead},
+			Validate:    requireTable,
+			Handler:     handleGetSchema,
+			Doc: ActionDoc{
+				Summary:  "Get the CREATE TABLE statement of a table",
+				Fields:   []string{"project_id", "table"},
+				Required: []string{"project_id", "table"},
+				Data: openapi.Object(map[string]*openapi.Schema{
+					"table":  openapi.String(""),
+					"schema": openapi.String("CREATE TABLE statement"),
+				}),
+			},
+		},
+		{
+			Name:        "insert",
+			NeedsDB:     true,
+			Permissions: []Permission{PermissionWrite},
+			Validate:    requireTableAndData,
+			Handler:     handleInsert,
+			Doc: ActionDoc{
+				Summary:  "Insert a row",
+				Fields:   []string{"project_id", "table", "data", "strict"},
+				Required: []string{"project_id", "table", "data"},
+				Data: openapi.Object(map[string]*openapi.Schema{
+					"inserted_id": openapi.Integer("Rowid of the new row"),
+				}),
+			},
+		},
+		{
+			Name:        "update",
+			NeedsDB:     true,
+			Permissions: []Permission{PermissionWrite},
+			Validate:    requireTableAndData,
+			Handler:     handleUpdate,
+			Doc: ActionDoc{
+				Summary:  "Update the rows matching a condition",
+				Fields:   fields([]string{"project_id", "table", "data", "strict"}, filterFields),
+				Required: []string{"project_id", "table", "data"},
+				Data:     rowsAffectedSchema,
+			},
+		},
+		{
+			Name:        "delete",
+			NeedsDB:     true,
+			Permissions: []Permission{PermissionWrite},
+			Validate:    requireTable,
+			Handler:     handleDelete,
+			Doc: ActionDoc{
+				Summary:  "Delete the rows matching a condition",
+				Fields:   fields([]string{"project_id", "table"}, filterFields),
+				Required: []string{"project_id", "table"},
+				Data:     rowsAffectedSchema,
+			},
+		},
+		{
+			Name:        "select",
+			NeedsDB:     true,
+			Permissions: []Permission{PermissionRead},
+			Validate:    requireTable,
+			Handler:     handleSelect,
+			Doc: ActionDoc{
+				Summary:  "Select rows from a table",
+				Fields:   fields([]string{"project_id", "table"}, filterFields, readFields),
+				Required: []string{"project_id", "table"},
+				Data:     openapi.Ref("Rows"),
+			},
+		},
+		{
+			Name:        "count",
+			NeedsDB:     true,
+			Permissions: []Permission{PermissionRead},
+			Validate:    requireTable,
+			Handler:     handleCount,
+			Doc: ActionDoc{
+				Summary:  "Count the rows matching a condition",
+				Fields:   fields([]string{"project_id", "table"}, filterFields),
+				Required: []string{"project_id", "table"},
+				Data:     countSchema,
+			},
+		},
+		{
+			Name:        "join",
+			NeedsDB:     true,
+			Permissions: []Permission{PermissionRead},
+			Validate:    requireJoinTables,
+			Handler:     handleJoin,
+			Doc: ActionDoc{
+				Summary:  "Join two tables",
+				Fields:   fields([]string{"project_id", "tables", "on", "join_type"}, filterFields, readFields),
+				Required: []string{"project_id", "tables", "on"},
+				Data:     openapi.Ref("Rows"),
+			},
+		},
+		{
+			Name:        "select_join",
+			NeedsDB:     true,
+			Permissions: []Permission{PermissionRead},
+			Validate:    requireJoins,
+			Handler:     handleSelectWithJoin,
+			Doc: ActionDoc{
+				Summary:  "Select rows from a table joined with others",
+				Fields:   fields([]string{"project_id", "table"}, groupFields, filterFields, readFields),
+				Required: []string{"project_id", "table", "joins"},
+				Data:     openapi.Ref("Rows"),
+			},
+		},
+		{
+			Name:        "count_join",
+			NeedsDB:     true,
+			Permissions: []Permission{PermissionRead},
+			Validate:    requireJoins,
+			Handler:     handleCountWithJoin,
+			Doc: ActionDoc{
+				Summary:  "Count the rows of a table joined with others",
+				Fields:   fields([]string{"project_id", "table"}, groupFields, filterFields),
+				Required: []string{"project_id", "table", "joins"},
+				Data:     countSchema,
+			},
+		},
+		{
+			Name:        "query_builder",
+			NeedsDB:     true,
+			Permissions: []Permission{PermissionRead},
+			Validate:    requireBaseTable,
+			Handler:     handleQueryBuilder,
+			Doc: ActionDoc{
+				Summary:  "Run a SELECT assembled from optional joins, filters, grouping and ordering",
+				Fields:   fields([]string{"project_id", "table"}, groupFields, filterFields, readFields),
+				Required: []string{"project_id", "table"},
+				Data:     openapi.Ref("Rows"),
+			},
+		},
+	}
+}
+
+// Validators of the built-in actions
+
+// requireProjectName rejects requests without a project name
+func requireProjectName(req *types.JSONRequest) error {
+	if req.ProjectName == "" {
+		return server.BadRequest("Project name is required")
+	}
+	return nil
+}
+
+// requireProjectID rejects requests without a valid project ID
+func requireProjectID(req *types.JSONRequest) error {
+	if req.ProjectID == "" {
+		return server.BadRequest("Project ID is required")
+	}
+	if !database.ValidProjectID(req.ProjectID) {
+		return server.BadRequest("Invalid project ID")
+	}
+	return nil
+}
+
+// requireTable rejects requests without a table name
+func requireTable(req *types.JSONRequest) error {
+	if req.Table == "" {
+		return server.BadRequest("Table name is required")
+	}
+	return nil
+}
+
+// requireTableAndData rejects requests without a table name or column values
+func requireTableAndData(req *types.JSONRequest) error {
+	if req.Table == "" || req.Data == nil {
+		return server.BadRequest("Table name and data are required")
+	}
+	return nil
+}
+
+// requireTableDefinition rejects create_table requests without a table name,
+// or without a schema or sample data to derive one from. Strict tables must
+// declare column types SQLite accepts in STRICT mode.
+func requireTableDefinition(req *types.JSONRequest) error {
+	if err := requireTable(req); err != nil {
+		return err
+	}
+	if req.Schema == nil && req.Data == nil {
+		return server.BadRequest("Schema or sample data is required")
+	}
+	if req.Strict && req.Schema != nil {
+		if err := checkStrictSchema(req.Schema); err != nil {
+			return invalidFields("Invalid schema: ", err)
+		}
+	}
+	return nil
+}
+
+// requireJoinTables rejects join requests without two tables and a condition
+func requireJoinTables(req *types.JSONRequest) error {
+	if len(req.Tables) < 2 {
+		return server.BadRequest("At least two tables are required for join")
+	}
+	if req.On == "" {
+		return server.BadRequest("Join condition (on) is required")
+	}
+	return nil
+}
+
+// requireBaseTable rejects query requests without a base table
+func requireBaseTable(req *types.JSONRequest) error {
+	if req.Table == "" {
+		return server.BadRequest("Base table name is required")
+	}
+	return nil
+}
+
+// requireJoins rejects join requests without a base table or joins
+func requireJoins(req *types.JSONRequest) error {
+	if err := requireBaseTable(req); err != nil {
+		return err
+	}
+	if len(req.Joins) == 0 {
+		return server.BadRequest("At least one join is required")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/config"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+)
+
+// cors applies the CORS policy; it is set by ApplyConfig
+var cors = server.NewCORS(server.CORSPolicy{})
+
+// publicRoutes are served without authentication, so any origin may read
+// them unless cors.routes overrides them
+var publicRoutes = []string{"/{$}", "/livez", "/readyz", "/metrics", "/api/openapi.json"}
+
+// applyCORS converts the CORS settings into the policy and route overrides
+func applyCORS(cfg config.CORSConfig) {
+	policy := server.CORSPolicy{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowCredentials: cfg.AllowCredentials,
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   cfg.ExposedHeaders,
+		MaxAge:           cfg.MaxAge,
+	}
+
+	public := server.CORSPolicy{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{http.MethodGet, http.MethodHead},
+		ExposedHeaders: []string{"X-Request-Id"},
+		MaxAge:         cfg.MaxAge,
+	}
+	routes := make(map[string]server.CORSPolicy, len(publicRoutes))
+	for _, pattern := range publicRoutes {
+		routes[pattern] = public
+	}
+
+	// Configured routes keep the default policy with their own origins
+	for pattern, origins := range cfg.RouteOrigins() {
+		override := policy
+		override.AllowedOrigins = origins
+		routes[pattern] = override
+	}
+
+	cors.SetPolicy(policy, routes)
+}
@@ -1,7 +1,7 @@
 package handlers
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -14,14 +14,15 @@ import (
 // Basic CRUD operations for database handlers
 
 // handleInsert handles record insertion
-func handleInsert(w http.ResponseWriter, req types.JSONRequest, db *database.DB) error {
-	if req.Table == "" || req.Data == nil {
-		return server.BadRequest("Table name and data are required")
+func handleInsert(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
+	data, err := writeData(req, db)
+	if err != nil {
+		return err
 	}
 
-	id, err := db.Insert(req.Table, req.Data)
+	id, err := db.Insert(req.Table, data)
 	if err != nil {
-		return server.InternalServerError("Failed to insert record: " + err.Error())
+		return database.QueryError("Failed to insert record", err)
 	}
 
 	response := types.JSONResponse{
@@ -34,41 +35,39 @@ func handleInsert(w http.ResponseWriter, req types.JSONRequest, db *database.DB)
 }
 
 // handleSelect handles record selection
-func handleSelect(w http.ResponseWriter, req types.JSONRequest, db *database.DB) error {
-	if req.Table == "" {
-		return server.BadRequest("Table name is required")
+func handleSelect(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
+	// Fall back to building a custom query for ORDER BY, LIMIT and OFFSET
+	if req.OrderBy != "" || req.Limit > 0 || req.Offset > 0 {
+		return handleSelectWithCustomQuery(w, r, req, db)
 	}
 
 	// Build query using the database Select method
 	rows, err := db.Select(req.Table, req.Columns, req.Where, req.WhereArgs...)
 	if err != nil {
-		return server.InternalServerError("Failed to execute query: " + err.Error())
+		return database.QueryError("Failed to execute query", err)
 	}
 	defer rows.Close()
 
-	data, err := rowsToMap(rows)
-	if err != nil {
-		return server.InternalServerError("Failed to process results: " + err.Error())
+	if req.Stream != "" {
+		return streamResult(w, r, req, rows)
 	}
 
-	// Apply ORDER BY, LIMIT, OFFSET at application level if needed
-	// Note: For better performance, these should be handled in the database layer
-	if req.OrderBy != "" || req.Limit > 0 || req.Offset > 0 {
-		// Fall back to building a custom query for these advanced features
-		return handleSelectWithCustomQuery(w, req, db)
+	data, count, err := rowsToResult(rows, req.ResultFormat)
+	if err != nil {
+		return database.QueryError("Failed to process results", err)
 	}
 
 	response := types.JSONResponse{
 		Success: true,
 		Data:    data,
-		Count:   int64(len(data)),
+		Count:   count,
 	}
 
 	return sendJSONResponse(w, response)
 }
 
 // handleSelectWithCustomQuery handles SELECT with ORDER BY, LIMIT, OFFSET
-func handleSelectWithCustomQuery(w http.ResponseWriter, req types.JSONRequest, db *database.DB) error {
+func handleSelectWithCustomQuery(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	// Build columns
 	columns := "*"
 	if len(req.Columns) > 0 {
@@ -99,19 +98,23 @@ func handleSelectWithCustomQuery(w http.ResponseWriter, req types.JSONRequest, d
 
 	rows, err := db.Query(query, args...)
 	if err != nil {
-		return server.InternalServerError("Failed to execute query: " + err.Error())
+		return database.QueryError("Failed to execute query", err)
 	}
 	defer rows.Close()
 
-	data, err := rowsToMap(rows)
+	if req.Stream != "" {
+		return streamResult(w, r, req, rows)
+	}
+
+	data, count, err := rowsToResult(rows, req.ResultFormat)
 	if err != nil {
-		return server.InternalServerError("Failed to process results: " + err.Error())
+		return database.QueryError("Failed to process results", err)
 	}
 
 	response := types.JSONResponse{
 		Success: true,
 		Data:    data,
-		Count:   int64(len(data)),
+		Count:   count,
 		Query:   query,
 	}
 
@@ -119,14 +122,15 @@ func handleSelectWithCustomQuery(w http.ResponseWriter, req types.JSONRequest, d
 }
 
 // handleUpdate handles record updates
-func handleUpdate(w http.ResponseWriter, req types.JSONRequest, db *database.DB) error {
-	if req.Table == "" || req.Data == nil {
-		return server.BadRequest("Table name and data are required")
+func handleUpdate(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
+	data, err := writeData(req, db)
+	if err != nil {
+		return err
 	}
 
-	rowsAffected, err := db.Update(req.Table, req.Data, req.Where, req.WhereArgs...)
+	rowsAffected, err := db.Update(req.Table, data, req.Where, req.WhereArgs...)
 	if err != nil {
-		return server.InternalServerError("Failed to update records: " + err.Error())
+		return database.QueryError("Failed to update records", err)
 	}
 
 	response := types.JSONResponse{
@@ -139,14 +143,10 @@ func handleUpdate(w http.ResponseWriter, req types.JSONRequest, db *database.DB)
 }
 
 // handleDelete handles record deletion
-func handleDelete(w http.ResponseWriter, req types.JSONRequest, db *database.DB) error {
-	if req.Table == "" {
-		return server.BadRequest("Table name is required")
-	}
-
+func handleDelete(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	rowsAffected, err := db.Delete(req.Table, req.Where, req.WhereArgs...)
 	if err != nil {
-		return server.InternalServerError("Failed to delete records: " + err.Error())
+		return database.QueryError("Failed to delete records", err)
 	}
 
 	response := types.JSONResponse{
@@ -159,14 +159,10 @@ func handleDelete(w http.ResponseWriter, req types.JSONRequest, db *database.DB)
 }
 
 // handleCount handles record counting
-func handleCount(w http.ResponseWriter, req types.JSONRequest, db *database.DB) error {
-	if req.Table == "" {
-		return server.BadRequest("Table name is required")
-	}
-
+func handleCount(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	count, err := db.Count(req.Table, req.Where, req.WhereArgs...)
 	if err != nil {
-		return server.InternalServerError("Failed to count records: " + err.Error())
+		return database.QueryError("Failed to count records", err)
 	}
 
 	response := types.JSONResponse{
@@ -178,9 +174,39 @@ func handleCount(w http.ResponseWriter, req types.JSONRequest, db *database.DB)
 	return sendJSONResponse(w, response)
 }
 
+// writeData returns the values to write, coerced to the table schema in strict mode
+func writeData(req types.JSONRequest, db *database.DB) (map[string]interface{}, error) {
+	if !req.Strict {
+		return req.Data, nil
+	}
+
+	data, err := db.CoerceRow(req.Table, req.Data)
+	if err != nil {
+		var validationErr *database.ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, invalidFields("Invalid data: ", validationErr)
+		}
+		if errors.Is(err, database.ErrNoSuchTable) {
+			return nil, server.NotFound(err.Error()).WithCode(server.ErrCodeTableNotFound)
+		}
+		return nil, database.QueryError("Failed to read table schema", err)
+	}
+	return data, nil
+}
+
+// invalidFields creates the 400 error for fields that failed validation, with
+// each field's problem in error_details
+func invalidFields(message string, err *database.ValidationError) error {
+	httpErr := server.BadRequest(message + err.Error()).WithCode(server.ErrCodeValidationFailed)
+	for _, field := range err.Fields {
+		httpErr = httpErr.WithDetail(field.Field, field.Message)
+	}
+	return httpErr
+}
+
 // Helper function to convert SQL rows to map slice
-func rowsToMap(rows *sql.Rows) ([]map[string]interface{}, error) {
-	columns, err := rows.Columns()
+func rowsToMap(rows *database.Rows) ([]map[string]interface{}, error) {
+	columns, err := resultColumns(rows)
 	if err != nil {
 		return nil, err
 	}
@@ -200,25 +226,21 @@ func rowsToMap(rows *sql.Rows) ([]map[string]interface{}, error) {
 			return nil, err
 		}
 
-		// Create map for this row
-		rowMap := make(map[string]interface{})
-		for i, col := range columns {
-			val := values[i]
-
-			// Convert []byte to string if necessary
-			if b, ok := val.([]byte); ok {
-				val = string(b)
-			}
-
-			rowMap[col] = val
-		}
-
-		results = append(results, rowMap)
+		results = append(results, rowToMap(columns, values))
 	}
 
 	return results, rows.Err()
 }
 
+// rowToMap builds the result map for a single scanned row
+func rowToMap(columns []resultColumn, values []interface{}) map[string]interface{} {
+	rowMap := make(map[string]interface{}, len(columns))
+	for i, col := range columns {
+		rowMap[col.Name] = col.encode(values[i])
+	}
+	return rowMap
+}
+
 // Helper function to send JSON response
 func sendJSONResponse(w http.ResponseWriter, response types.JSONResponse) error {
 	w.Header().Set("Content-Type", "application/json")
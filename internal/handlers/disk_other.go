@@ -0,0 +1,13 @@
+//go:build !unix
+
+package handlers
+
+import "errors"
+
+// errDiskSpaceUnsupported is returned where free disk space cannot be measured
+var errDiskSpaceUnsupported = errors.New("free disk space is not supported on this platform")
+
+// freeDiskSpace is not implemented on this platform
+func freeDiskSpace(path string) (uint64, error) {
+	return 0, errDiskSpaceUnsupported
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
+
+// Result formats for row data
+const (
+	resultFormatObjects = "objects" // One JSON object per row (default)
+	resultFormatTyped   = "typed"   // Column metadata header plus one array per row
+)
+
+// maxSafeJSONInteger is the largest integer a JSON number can carry without precision loss
+const maxSafeJSONInteger = 1<<53 - 1
+
+// resultColumn describes a result column and how its values are encoded
+type resultColumn struct {
+	Name         string `json:"name"`
+	DeclaredType string `json:"declared_type,omitempty"`
+	Kind         string `json:"kind"`
+}
+
+// typedResult is the typed result format: a column header followed by row arrays
+type typedResult struct {
+	Columns []resultColumn  `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// blobValue marks base64 encoded BLOB data in results
+type blobValue struct {
+	Type   string `json:"$type"`
+	Base64 string `json:"base64"`
+}
+
+// resolveResultFormat validates the requested result format
+func resolveResultFormat(req *types.JSONRequest) error {
+	req.ResultFormat = strings.ToLower(req.ResultFormat)
+	switch req.ResultFormat {
+	case "", resultFormatObjects, resultFormatTyped:
+		return nil
+	default:
+		return server.BadRequest(fmt.Sprintf("Unsupported result format: %s", req.ResultFormat))
+	}
+}
+
+// resultColumns describes the columns of a result set from their declared types
+func resultColumns(rows *database.Rows) ([]resultColumn, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	return describeColumns(columnTypes), nil
+}
+
+// describeColumns maps column types to result columns
+func describeColumns(columnTypes []*sql.ColumnType) []resultColumn {
+	columns := make([]resultColumn, len(columnTypes))
+	for i, ct := range columnTypes {
+		declared := strings.ToUpper(ct.DatabaseTypeName())
+		columns[i] = resultColumn{
+			Name:         ct.Name(),
+			DeclaredType: declared,
+			Kind:         database.ColumnKind(declared),
+		}
+	}
+	return columns
+}
+
+// encode converts a scanned value into its JSON representation
+func (c resultColumn) encode(val interface{}) interface{} {
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case []byte:
+		// The driver only returns []byte for values stored as BLOB
+		return blobValue{Type: database.KindBlob, Base64: base64.StdEncoding.EncodeToString(v)}
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case int64:
+		if c.Kind == database.KindBoolean {
+			return v != 0
+		}
+		if v > maxSafeJSONInteger || v < -maxSafeJSONInteger {
+			return strconv.FormatInt(v, 10)
+		}
+		return v
+	case string:
+		if c.Kind == database.KindBoolean {
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b
+			}
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// rowsToResult reads all rows in the requested result format and returns the row count
+func rowsToResult(rows *database.Rows, format string) (interface{}, int64, error) {
+	if format != resultFormatTyped {
+		data, err := rowsToMap(rows)
+		return data, int64(len(data)), err
+	}
+
+	columns, err := resultColumns(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := typedResult{Columns: columns, Rows: [][]interface{}{}}
+	values, valuePtrs := scanTargets(len(columns))
+	for rows.Next() {
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, 0, err
+		}
+		result.Rows = append(result.Rows, rowToSlice(columns, values))
+	}
+
+	return result, int64(len(result.Rows)), rows.Err()
+}
+
+// rowToSlice builds the typed result array for a single scanned row
+func rowToSlice(columns []resultColumn, values []interface{}) []interface{} {
+	row := make([]interface{}, len(columns))
+	for i, col := range columns {
+		row[i] = col.encode(values[i])
+	}
+	return row
+}
+
+// scanTargets allocates a value slice and matching Scan destinations
+func scanTargets(n int) ([]interface{}, []interface{}) {
+	values := make([]interface{}, n)
+	valuePtrs := make([]interface{}, n)
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	return values, valuePtrs
+}
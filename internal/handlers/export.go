@@ -0,0 +1,412 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/logging"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
+
+// exportFlushInterval is the number of rows written between flushes to the client
+const exportFlushInterval = 500
+
+// rowExporter writes a result set in a specific export format
+type rowExporter interface {
+	// Begin writes any preamble (header row, CREATE statement, ...)
+	Begin(columns []*sql.ColumnType) error
+	// WriteRow writes a single scanned row
+	WriteRow(values []interface{}) error
+	// Flush flushes buffered output to the underlying writer
+	Flush() error
+	// End writes any trailer and flushes remaining output
+	End() error
+}
+
+// exportFormat describes a supported export format
+type exportFormat struct {
+	contentType string
+	extension   string
+	newExporter func(w io.Writer, table, schema string) rowExporter
+}
+
+var exportFormats = map[string]exportFormat{
+	"csv": {
+		contentType: "text/csv; charset=utf-8",
+		extension:   "csv",
+		newExporter: func(w io.Writer, table, schema string) rowExporter {
+			return &csvExporter{w: csv.NewWriter(w)}
+		},
+	},
+	"ndjson": {
+		contentType: "application/x-ndjson",
+		extension:   "ndjson",
+		newExporter: func(w io.Writer, table, schema string) rowExporter {
+			return &ndjsonExporter{enc: json.NewEncoder(w)}
+		},
+	},
+	"sql": {
+		contentType: "application/sql; charset=utf-8",
+		extension:   "sql",
+		newExporter: func(w io.Writer, table, schema string) rowExporter {
+			return &sqlExporter{w: w, table: table, schema: schema}
+		},
+	},
+}
+
+// ExportHandler streams a table or query result as CSV, NDJSON or a SQL dump.
+// GET requests take table, format, columns, order_by, limit and offset from the
+// query string; POST requests take a select/query_builder style JSON body.
+func ExportHandler(w http.ResponseWriter, r *http.Request) error {
+	db := database.GetDBFromContext(r)
+	if db == nil {
+		return server.InternalServerError("Database connection not available")
+	}
+
+	req, err := parseExportRequest(r)
+	if err != nil {
+		return err
+	}
+
+	if req.Table == "" {
+		return server.BadRequest("Table name is required")
+	}
+
+	formatName := strings.ToLower(req.Format)
+	if formatName == "" {
+		formatName = "csv"
+	}
+	format, ok := exportFormats[formatName]
+	if !ok {
+		return server.BadRequest(fmt.Sprintf("Unsupported export format: %s", req.Format))
+	}
+
+	// A plain table export keeps the original CREATE TABLE statement in SQL dumps
+	var schema string
+	if formatName == "sql" && isPlainTableExport(req) {
+		schema, err = db.GetTableSchema(req.Table)
+		if err != nil {
+			return database.QueryError("Failed to get table schema", err)
+		}
+	}
+
+	query, args := buildSelectQuery(req)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return database.QueryError("Failed to execute export query", err)
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return database.QueryError("Failed to read result columns", err)
+	}
+
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="%s.%s"`, exportFileName(req.Table), format.extension))
+	w.Header().Set("Trailer", "X-Stream-Status, X-Stream-Error")
+	w.WriteHeader(http.StatusOK)
+
+	// Headers are sent at this point, so failures are reported in the trailers
+	if err := streamRows(w, rows, format.newExporter(w, req.Table, schema), columnTypes); err != nil {
+		logging.FromContext(r.Context()).Warn("Export aborted", "table", req.Table, "error", err)
+		w.Header().Set("X-Stream-Status", "error")
+		w.Header().Set("X-Stream-Error", "Export aborted: "+err.Error())
+	} else {
+		w.Header().Set("X-Stream-Status", "ok")
+	}
+	return nil
+}
+
+// parseExportRequest builds an export request from the query string or JSON body
+func parseExportRequest(r *http.Request) (types.JSONRequest, error) {
+	var req types.JSONRequest
+
+	if r.Method == http.MethodPost {
+		var err error
+		if req, err = decodeJSONRequest(r); err != nil {
+			return req, err
+		}
+		if req.Action != "" && req.Action != "select" && req.Action != "query_builder" {
+			return req, server.BadRequest(fmt.Sprintf("Action %s cannot be exported", req.Action))
+		}
+		return req, nil
+	}
+
+	query := r.URL.Query()
+	req.Table = query.Get("table")
+	req.Format = query.Get("format")
+	req.OrderBy = query.Get("order_by")
+	if columns := query.Get("columns"); columns != "" {
+		req.Columns = strings.Split(columns, ",")
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return req, server.BadRequest("Invalid limit: " + limit)
+		}
+		req.Limit = n
+	}
+	if offset := query.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return req, server.BadRequest("Invalid offset: " + offset)
+		}
+		req.Offset = n
+	}
+
+	return req, nil
+}
+
+// isPlainTableExport reports whether the request exports whole rows of a single table
+func isPlainTableExport(req types.JSONRequest) bool {
+	return len(req.Columns) == 0 && len(req.Joins) == 0 && req.GroupBy == ""
+}
+
+// buildSelectQuery compiles a select/query_builder request through the QueryBuilder
+func buildSelectQuery(req types.JSONRequest) (string, []interface{}) {
+	qb := database.NewQueryBuilder(req.Table)
+
+	if len(req.Columns) > 0 {
+		qb.Select(req.Columns...)
+	}
+	for _, join := range req.Joins {
+		joinType := database.InnerJoin
+		if join.Type != "" {
+			joinType = database.JoinType(strings.ToUpper(join.Type) + " JOIN")
+		}
+		qb.Join(joinType, join.Table, join.Condition)
+	}
+	if req.Where != "" {
+		qb.Where(req.Where, req.WhereArgs...)
+	}
+	qb.GroupBy(req.GroupBy).Having(req.Having).OrderBy(req.OrderBy)
+	if req.Limit > 0 {
+		qb.Limit(req.Limit)
+	}
+	if req.Offset > 0 {
+		qb.Offset(req.Offset)
+	}
+
+	return qb.Build()
+}
+
+// streamRows scans rows one at a time and hands them to the exporter
+func streamRows(w http.ResponseWriter, rows *database.Rows, exporter rowExporter, columnTypes []*sql.ColumnType) error {
+	if err := exporter.Begin(columnTypes); err != nil {
+		return err
+	}
+
+	flusher, _ := w.(http.Flusher)
+	values, valuePtrs := scanTargets(len(columnTypes))
+
+	written := 0
+	for rows.Next() {
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return err
+		}
+		if err := exporter.WriteRow(values); err != nil {
+			return err
+		}
+
+		written++
+		if written%exportFlushInterval == 0 {
+			if err := exporter.Flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return exporter.End()
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// exportFileName derives a safe download file name from a table name
+func exportFileName(table string) string {
+	name := strings.Trim(unsafeFileNameChars.ReplaceAllString(table, "_"), "._")
+	if name == "" {
+		return "export"
+	}
+	return name
+}
+
+// csvExporter writes rows as CSV with a header row of column names
+type csvExporter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (e *csvExporter) Begin(columns []*sql.ColumnType) error {
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.Name()
+	}
+	e.record = make([]string, len(columns))
+	return e.w.Write(header)
+}
+
+func (e *csvExporter) WriteRow(values []interface{}) error {
+	for i, val := range values {
+		e.record[i] = csvValue(val)
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvExporter) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExporter) End() error {
+	return e.Flush()
+}
+
+// csvValue formats a scanned value as a CSV field; NULL becomes an empty field
+// and BLOBs are base64 encoded, as in JSON responses
+func csvValue(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ndjsonExporter writes one JSON object per line
+type ndjsonExporter struct {
+	enc     *json.Encoder
+	columns []resultColumn
+}
+
+func (e *ndjsonExporter) Begin(columns []*sql.ColumnType) error {
+	e.columns = describeColumns(columns)
+	return nil
+}
+
+func (e *ndjsonExporter) WriteRow(values []interface{}) error {
+	return e.enc.Encode(rowToMap(e.columns, values))
+}
+
+func (e *ndjsonExporter) Flush() error {
+	return nil
+}
+
+func (e *ndjsonExporter) End() error {
+	return nil
+}
+
+// sqlExporter writes a portable dump of CREATE TABLE and INSERT statements
+type sqlExporter struct {
+	w       io.Writer
+	table   string
+	schema  string
+	columns string
+}
+
+func (e *sqlExporter) Begin(columns []*sql.ColumnType) error {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = quoteIdentifier(col.Name())
+	}
+	e.columns = strings.Join(names, ", ")
+
+	// Without the original schema, derive one from the result columns
+	schema := e.schema
+	if schema == "" {
+		defs := make([]string, len(columns))
+		for i, col := range columns {
+			defs[i] = strings.TrimSpace(quoteIdentifier(col.Name()) + " " + col.DatabaseTypeName())
+		}
+		schema = fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdentifier(e.table), strings.Join(defs, ", "))
+	}
+
+	_, err := fmt.Fprintf(e.w, "BEGIN TRANSACTION;\n%s;\n", schema)
+	return err
+}
+
+func (e *sqlExporter) WriteRow(values []interface{}) error {
+	literals := make([]string, len(values))
+	for i, val := range values {
+		literals[i] = sqlLiteral(val)
+	}
+	_, err := fmt.Fprintf(e.w, "INSERT INTO %s (%s) VALUES (%s);\n",
+		quoteIdentifier(e.table), e.columns, strings.Join(literals, ", "))
+	return err
+}
+
+func (e *sqlExporter) Flush() error {
+	return nil
+}
+
+func (e *sqlExporter) End() error {
+	_, err := io.WriteString(e.w, "COMMIT;\n")
+	return err
+}
+
+// quoteIdentifier quotes a SQL identifier using double quotes
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// sqlLiteral renders a scanned value as a SQL literal
+func sqlLiteral(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		return "X'" + hex.EncodeToString(v) + "'"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		// SQLite reads out-of-range literals as infinities and has no NaN
+		switch {
+		case math.IsInf(v, 1):
+			return "9e999"
+		case math.IsInf(v, -1):
+			return "-9e999"
+		case math.IsNaN(v):
+			return "NULL"
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return "'" + v.Format(time.RFC3339Nano) + "'"
+	default:
+		return "'" + strings.ReplaceAll(fmt.Sprint(v), "'", "''") + "'"
+	}
+}
@@ -6,21 +6,11 @@ import (
 	"strings"
 
 	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
-	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
 	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
 )
 
 // handleJoin handles simple join queries
-func handleJoin(w http.ResponseWriter, req types.JSONRequest, db *database.DB) error {
-	// Validate required fields
-	if len(req.Tables) < 2 {
-		return server.BadRequest("At least two tables are required for join")
-	}
-
-	if req.On == "" {
-		return server.BadRequest("Join condition (on) is required")
-	}
-
+func handleJoin(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	// Build the join query
 	baseTable := req.Tables[0]
 	joinTable := req.Tables[1]
@@ -63,19 +53,23 @@ func handleJoin(w http.ResponseWriter, req types.JSONRequest, db *database.DB) e
 	// Execute the join query
 	rows, err := db.Query(query, args...)
 	if err != nil {
-		return server.InternalServerError("Failed to execute join query: " + err.Error())
+		return database.QueryError("Failed to execute join query", err)
 	}
 	defer rows.Close()
 
-	data, err := rowsToMap(rows)
+	if req.Stream != "" {
+		return streamResult(w, r, req, rows)
+	}
+
+	data, count, err := rowsToResult(rows, req.ResultFormat)
 	if err != nil {
-		return server.InternalServerError("Failed to process join results: " + err.Error())
+		return database.QueryError("Failed to process join results", err)
 	}
 
 	response := types.JSONResponse{
 		Success: true,
 		Data:    data,
-		Count:   int64(len(data)),
+		Count:   count,
 		Query:   query,
 	}
 
@@ -83,15 +77,7 @@ func handleJoin(w http.ResponseWriter, req types.JSONRequest, db *database.DB) e
 }
 
 // handleSelectWithJoin handles SELECT queries with joins using the Joins array
-func handleSelectWithJoin(w http.ResponseWriter, req types.JSONRequest, db *database.DB) error {
-	if req.Table == "" {
-		return server.BadRequest("Base table name is required")
-	}
-
-	if len(req.Joins) == 0 {
-		return server.BadRequest("At least one join is required")
-	}
-
+func handleSelectWithJoin(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	// Build columns to select
 	columns := "*"
 	if len(req.Columns) > 0 {
@@ -143,19 +129,23 @@ func handleSelectWithJoin(w http.ResponseWriter, req types.JSONRequest, db *data
 	// Execute the query
 	rows, err := db.Query(query, args...)
 	if err != nil {
-		return server.InternalServerError("Failed to execute select with joins: " + err.Error())
+		return database.QueryError("Failed to execute select with joins", err)
 	}
 	defer rows.Close()
 
-	data, err := rowsToMap(rows)
+	if req.Stream != "" {
+		return streamResult(w, r, req, rows)
+	}
+
+	data, count, err := rowsToResult(rows, req.ResultFormat)
 	if err != nil {
-		return server.InternalServerError("Failed to process results: " + err.Error())
+		return database.QueryError("Failed to process results", err)
 	}
 
 	response := types.JSONResponse{
 		Success: true,
 		Data:    data,
-		Count:   int64(len(data)),
+		Count:   count,
 		Query:   query,
 	}
 
@@ -163,15 +153,7 @@ func handleSelectWithJoin(w http.ResponseWriter, req types.JSONRequest, db *data
 }
 
 // handleCountWithJoin handles COUNT queries with joins
-func handleCountWithJoin(w http.ResponseWriter, req types.JSONRequest, db *database.DB) error {
-	if req.Table == "" {
-		return server.BadRequest("Base table name is required")
-	}
-
-	if len(req.Joins) == 0 {
-		return server.BadRequest("At least one join is required")
-	}
-
+func handleCountWithJoin(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	// Start building the query
 	query := fmt.Sprintf("SELECT COUNT(*) FROM %s", req.Table)
 
@@ -205,7 +187,7 @@ func handleCountWithJoin(w http.ResponseWriter, req types.JSONRequest, db *datab
 	var count int64
 	err := db.QueryRow(query, args...).Scan(&count)
 	if err != nil {
-		return server.InternalServerError("Failed to execute count with joins: " + err.Error())
+		return database.QueryError("Failed to execute count with joins", err)
 	}
 
 	response := types.JSONResponse{
@@ -219,11 +201,7 @@ func handleCountWithJoin(w http.ResponseWriter, req types.JSONRequest, db *datab
 }
 
 // handleQueryBuilder handles complex queries using a query builder approach
-func handleQueryBuilder(w http.ResponseWriter, req types.JSONRequest, db *database.DB) error {
-	if req.Table == "" {
-		return server.BadRequest("Base table name is required")
-	}
-
+func handleQueryBuilder(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	// Build columns to select
 	columns := "*"
 	if len(req.Columns) > 0 {
@@ -275,19 +253,23 @@ func handleQueryBuilder(w http.ResponseWriter, req types.JSONRequest, db *databa
 	// Execute the query
 	rows, err := db.Query(query, args...)
 	if err != nil {
-		return server.InternalServerError("Failed to execute query: " + err.Error())
+		return database.QueryError("Failed to execute query", err)
 	}
 	defer rows.Close()
 
-	data, err := rowsToMap(rows)
+	if req.Stream != "" {
+		return streamResult(w, r, req, rows)
+	}
+
+	data, count, err := rowsToResult(rows, req.ResultFormat)
 	if err != nil {
-		return server.InternalServerError("Failed to process results: " + err.Error())
+		return database.QueryError("Failed to process results", err)
 	}
 
 	response := types.JSONResponse{
 		Success: true,
 		Data:    data,
-		Count:   int64(len(data)),
+		Count:   count,
 		Query:   query,
 	}
 
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/metrics"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+)
+
+// databaseActions counts DatabaseHandler actions by action and response status
+var databaseActions = metrics.NewCounterVec("pebbledb_db_actions_total",
+	"Actions handled by the JSON database endpoint, by action and status code.",
+	"action", "status")
+
+// recordAction counts a handled action. Unknown actions share one label so
+// clients cannot create unbounded series.
+func recordAction(action string, err error) {
+	status := http.StatusOK
+	if err != nil {
+		httpErr := server.AsHTTPError(err)
+		status = httpErr.Code
+		if httpErr.ErrorCode == server.ErrCodeUnknownAction {
+			action = "unknown"
+		}
+	}
+	databaseActions.Inc(action, strconv.Itoa(status))
+}
+
+// MetricsHandler serves all metrics in the Prometheus text exposition format
+func MetricsHandler(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", metrics.ContentType)
+	return metrics.WriteTo(w)
+}
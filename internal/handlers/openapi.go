@@ -0,0 +1,530 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/config"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/openapi"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+)
+
+// The OpenAPI document is generated from the server's route table, the route
+// documentation below and the documentation of the registered /api/db actions.
+// It is built and validated when the routes are set up, so a route or action
+// that refers to an undefined schema stops the server from starting.
+
+// routeDoc documents a route for the OpenAPI document
+type routeDoc struct {
+	Summary     string
+	Tag         string
+	Public      bool // Served without authentication
+	Query       []openapi.Parameter
+	Body        *openapi.RequestBody
+	Status      string // Status of the documented response (default 200)
+	Response    *openapi.Schema
+	ContentType string // Media type of Response (default application/json)
+}
+
+// routeDocs documents the routes registered by SetupRoutes, keyed by method
+// and pattern. Path parameters are documented automatically.
+var routeDocs = map[string]routeDoc{
+	"GET /{$}": {
+		Summary: "Welcome message", Tag: "probes", Public: true,
+		Response: openapi.String(""), ContentType: "text/plain",
+	},
+	"GET /livez": {
+		Summary: "Liveness probe", Tag: "probes", Public: true,
+		Response: envelope(openapi.Object(map[string]*openapi.Schema{"status": openapi.Enum("", "ok")})),
+	},
+	"GET /readyz": {
+		Summary: "Readiness probe; 503 while a dependency check fails", Tag: "probes", Public: true,
+		Response: envelope(openapi.Object(map[string]*openapi.Schema{
+			"status": openapi.Enum("", "ready", "not_ready"),
+			"checks": openapi.Map(openapi.Any(""), "Check results by name"),
+		})),
+	},
+	"GET /metrics": {
+		Summary: "Prometheus metrics", Tag: "probes", Public: true,
+		Response: openapi.String(""), ContentType: "text/plain",
+	},
+	"GET /api/openapi.json": {
+		Summary: "This OpenAPI document", Tag: "meta", Public: true,
+		Response: openapi.Any("OpenAPI 3 document"),
+	},
+	"GET /api/health": {
+		Summary: "Server health and memory usage", Tag: "meta",
+		Response: envelope(openapi.Map(openapi.Any(""), "")),
+	},
+	"GET /api/stats": {
+		Summary: "Database statistics (not implemented)", Tag: "meta",
+		Status: "501", Response: openapi.Ref("Error"),
+	},
+	"GET /api/tables": {
+		Summary: "Table listing (not implemented)", Tag: "meta",
+		Status: "501", Response: openapi.Ref("Error"),
+	},
+	"POST /api/db": {
+		Summary: "Run a database action", Tag: "actions",
+		Body: &openapi.RequestBody{
+			Required: true,
+			Content:  map[string]openapi.MediaType{"application/json": {Schema: openapi.Ref("DatabaseRequest")}},
+		},
+		Response: openapi.Ref("DatabaseResponse"),
+	},
+	"POST /api/projects/upload": {
+		Summary: "Create a project from an uploaded SQLite database file", Tag: "projects",
+		Query: []openapi.Parameter{
+			{Name: "name", In: "query", Required: true, Schema: openapi.String("Project name")},
+			{Name: "description", In: "query", Schema: openapi.String("Project description")},
+		},
+		Body: &openapi.RequestBody{
+			Required: true,
+			Content: map[string]openapi.MediaType{
+				"application/octet-stream": {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+			},
+		},
+		Status:   "201",
+		Response: envelope(openapi.Ref("Project")),
+	},
+	"GET /api/projects/download": {
+		Summary: "Download a project's SQLite database file", Tag: "projects",
+		Query:       []openapi.Parameter{projectQuery},
+		Response:    &openapi.Schema{Type: "string", Format: "binary"},
+		ContentType: "application/vnd.sqlite3",
+	},
+	"GET /api/export": {
+		Summary: "Export a table as CSV, NDJSON or a SQL dump", Tag: "export",
+		Query: []openapi.Parameter{
+			projectQuery,
+			{Name: "table", In: "query", Required: true, Schema: openapi.String("")},
+			{Name: "format", In: "query", Schema: exportFormatSchema},
+			{Name: "columns", In: "query", Schema: openapi.String("Comma-separated columns to export")},
+			{Name: "order_by", In: "query", Schema: openapi.String("ORDER BY clause")},
+			{Name: "limit", In: "query", Schema: openapi.Integer("")},
+			{Name: "offset", In: "query", Schema: openapi.Integer("")},
+		},
+		Response: openapi.String("Exported rows"), ContentType: "text/csv",
+	},
+	"POST /api/export": {
+		Summary: "Export a select or query_builder result as CSV, NDJSON or a SQL dump", Tag: "export",
+		Body: &openapi.RequestBody{
+			Required: true,
+			Content:  map[string]openapi.MediaType{"application/json": {Schema: openapi.Ref("ExportRequest")}},
+		},
+		Response: openapi.String("Exported rows"), ContentType: "text/csv",
+	},
+	"GET /api/projects/{project}/tables/{table}/schema": {
+		Summary: "Get the schema and columns of a table", Tag: "rows",
+		Response: envelope(openapi.Object(map[string]*openapi.Schema{
+			"table":   openapi.String(""),
+			"schema":  openapi.String("CREATE TABLE statement"),
+			"columns": openapi.Array(openapi.Ref("Column"), ""),
+		})),
+	},
+	"GET /api/projects/{project}/tables/{table}/rows": {
+		Summary: "List rows with filters, embedded resources, ordering and pagination", Tag: "rows",
+		Query: []openapi.Parameter{
+			{Name: "select", In: "query", Schema: openapi.String("Columns and embedded resources, e.g. id,name,orders(id,total)")},
+			{Name: "order", In: "query", Schema: openapi.String("Ordering, e.g. created_at.desc.nullslast,name")},
+			{Name: "limit", In: "query", Schema: openapi.Integer("")},
+			{Name: "offset", In: "query", Schema: openapi.Integer("")},
+			{Name: "stream", In: "query", Schema: requestFields["stream"]},
+			{Name: "result_format", In: "query", Schema: requestFields["result_format"]},
+			{Name: "or", In: "query", Schema: openapi.String("Alternatives, e.g. (age.lt.18,age.gt.65)")},
+			{Name: "and", In: "query", Schema: openapi.String("Conjunction, e.g. (name.eq.bob,or(age.eq.1,age.eq.2))")},
+			{Name: "filters", In: "query", Schema: openapi.Map(openapi.String(""),
+				"Column filters such as age=gte.18, name=like.A* or status=in.(new,open)")},
+		},
+		Response: rowsResponse,
+	},
+	"POST /api/projects/{project}/tables/{table}/rows": {
+		Summary: "Insert a row", Tag: "rows",
+		Query:  []openapi.Parameter{strictQuery},
+		Body:   rowBody,
+		Status: "201",
+		Response: envelope(openapi.Object(map[string]*openapi.Schema{
+			"inserted_id": openapi.Integer("Rowid of the new row"),
+		})),
+	},
+	"GET /api/projects/{project}/tables/{table}/rows/{pk}": {
+		Summary: "Get a row by primary key", Tag: "rows",
+		Response: envelope(openapi.Map(openapi.Any(""), "Column values by column name")),
+	},
+	"PATCH /api/projects/{project}/tables/{table}/rows/{pk}": {
+		Summary: "Update a row by primary key", Tag: "rows",
+		Query:    []openapi.Parameter{strictQuery},
+		Body:     rowBody,
+		Response: envelope(rowsAffectedSchema),
+	},
+	"DELETE /api/projects/{project}/tables/{table}/rows/{pk}": {
+		Summary: "Delete a row by primary key", Tag: "rows",
+		Response: envelope(rowsAffectedSchema),
+	},
+}
+
+// Parameters and bodies shared by several routes
+var (
+	projectQuery = openapi.Parameter{Name: "project", In: "query", Required: true, Schema: openapi.String("Project ID")}
+	strictQuery  = openapi.Parameter{Name: "strict", In: "query", Schema: requestFields["strict"]}
+	rowBody      = &openapi.RequestBody{
+		Required: true,
+		Content: map[string]openapi.MediaType{
+			"application/json": {Schema: openapi.Map(openapi.Any(""), "Column values by column name")},
+		},
+	}
+	rowsResponse       = envelope(openapi.Ref("Rows"))
+	exportFormatSchema = openapi.Enum("Export format (default csv)", "csv", "ndjson", "sql")
+)
+
+// openAPITags describes the operation tags in display order
+var openAPITags = []openapi.Tag{
+	{Name: "actions", Description: "The JSON action API; the action field selects the operation"},
+	{Name: "rows", Description: "RESTful table and row resources"},
+	{Name: "export", Description: "Streaming exports"},
+	{Name: "projects", Description: "Project database files"},
+	{Name: "meta", Description: "Server information"},
+	{Name: "probes", Description: "Unauthenticated health probes and metrics"},
+}
+
+// openAPIDocument holds the encoded document served at /api/openapi.json
+var openAPIDocument []byte
+
+// OpenAPIHandler serves the OpenAPI document describing the server's routes
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) error {
+	if openAPIDocument == nil {
+		return server.InternalServerError("OpenAPI document not available")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err := w.Write(openAPIDocument)
+	return err
+}
+
+// buildOpenAPI generates and validates the OpenAPI document for routes
+func buildOpenAPI(routes []server.Route, cfg *config.Config) (*openapi.Document, error) {
+	schemas, err := actionSchemas()
+	if err != nil {
+		return nil, err
+	}
+	for name, schema := range sharedSchemas() {
+		schemas[name] = schema
+	}
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "PebbleDB",
+			Version:     "1.0.0",
+			Description: "Multi-tenant SQLite databases over HTTP.",
+		},
+		Paths:      map[string]openapi.PathItem{},
+		Components: openapi.Components{Schemas: schemas},
+		Tags:       openAPITags,
+	}
+	if cfg.Auth.Enabled {
+		doc.Components.SecuritySchemes = map[string]openapi.SecurityScheme{
+			"cookieAuth": {
+				Type:        "apiKey",
+				In:          "cookie",
+				Name:        cfg.Auth.TokenName,
+				Description: "Access token cookie issued by the auth provider",
+			},
+		}
+		doc.Security = []openapi.SecurityRequirement{{"cookieAuth": {}}}
+	}
+
+	for _, route := range routes {
+		path, params := openAPIPath(route.Pattern)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = openapi.PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = openAPIOperation(route, params)
+	}
+
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// openAPIPath converts a ServeMux pattern to an OpenAPI path and its path
+// parameters: "{$}" is dropped and "{name...}" becomes "{name}"
+func openAPIPath(pattern string) (string, []string) {
+	path := strings.TrimSuffix(pattern, "{$}")
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name := strings.TrimSuffix(strings.Trim(segment, "{}"), "...")
+			segments[i] = "{" + name + "}"
+			params = append(params, name)
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// openAPIOperation describes a route, from its documentation if it has any
+func openAPIOperation(route server.Route, params []string) *openapi.Operation {
+	key := route.Method + " " + route.Pattern
+	doc, ok := routeDocs[key]
+	if !ok {
+		doc = routeDoc{Summary: key}
+	}
+
+	op := &openapi.Operation{
+		OperationID: operationID(route.Method, route.Pattern),
+		Summary:     doc.Summary,
+		Parameters:  append([]openapi.Parameter(nil), doc.Query...),
+		RequestBody: doc.Body,
+		Responses: map[string]openapi.Response{
+			"default": {
+				Description: "Error",
+				Content:     map[string]openapi.MediaType{"application/json": {Schema: openapi.Ref("Error")}},
+			},
+		},
+	}
+	if doc.Tag != "" {
+		op.Tags = []string{doc.Tag}
+	}
+	if doc.Public {
+		op.Security = &[]openapi.SecurityRequirement{}
+	}
+	for _, name := range params {
+		op.Parameters = append(op.Parameters, openapi.Parameter{
+			Name: name, In: "path", Required: true, Schema: openapi.String(""),
+		})
+	}
+
+	status := doc.Status
+	if status == "" {
+		status = "200"
+	}
+	code, _ := strconv.Atoi(status)
+	success := openapi.Response{Description: http.StatusText(code)}
+	if doc.Response != nil {
+		contentType := doc.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		success.Content = map[string]openapi.MediaType{contentType: {Schema: doc.Response}}
+		if contentType == "text/csv" {
+			// Exports are CSV by default and NDJSON or SQL on request
+			success.Content["application/x-ndjson"] = openapi.MediaType{Schema: doc.Response}
+			success.Content["application/sql"] = openapi.MediaType{Schema: doc.Response}
+		}
+		if doc.Response == rowsResponse || key == "POST /api/db" {
+			success.Content["application/x-ndjson"] = openapi.MediaType{
+				Schema: openapi.String("Streamed rows, one JSON object per line, followed by a status line"),
+			}
+		}
+	}
+	op.Responses[status] = success
+	return op
+}
+
+// operationID derives an operation ID from a route, e.g. "getProjectsTablesRowsByPk"
+// for GET /api/projects/{project}/tables/{table}/rows/{pk}
+func operationID(method, pattern string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	var param string
+	for _, segment := range strings.Split(strings.TrimPrefix(pattern, "/api"), "/") {
+		if strings.HasPrefix(segment, "{") {
+			param = strings.TrimSuffix(strings.Trim(segment, "{}"), "...")
+			continue
+		}
+		param = ""
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '.' || r == '_' || r == '-' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	if param == "$" {
+		b.WriteString("Root")
+	} else if param != "" {
+		b.WriteString("By" + strings.ToUpper(param[:1]) + param[1:])
+	}
+	return b.String()
+}
+
+// envelope returns the schema of a successful JSONResponse carrying data
+func envelope(data *openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{AllOf: []*openapi.Schema{
+		openapi.Ref("Envelope"),
+		openapi.Object(map[string]*openapi.Schema{"data": data}),
+	}}
+}
+
+// sharedSchemas returns the schemas referenced across routes and actions
+func sharedSchemas() map[string]*openapi.Schema {
+	resultColumn := openapi.Object(map[string]*openapi.Schema{
+		"name":          openapi.String(""),
+		"declared_type": openapi.String("Declared column type, if any"),
+		"kind":          openapi.String("How values are encoded, e.g. integer, real, text or blob"),
+	}, "name", "kind")
+
+	exportFields := map[string]*openapi.Schema{
+		"action":     openapi.Enum("", "select", "query_builder"),
+		"project_id": requestFields["project_id"],
+		"table":      requestFields["table"],
+		"format":     exportFormatSchema,
+	}
+	for _, field := range fields(groupFields, filterFields, []string{"columns", "order_by", "limit", "offset"}) {
+		exportFields[field] = requestFields[field]
+	}
+
+	return map[string]*openapi.Schema{
+		"Envelope": openapi.Object(map[string]*openapi.Schema{
+			"success":   openapi.Boolean(""),
+			"count":     openapi.Integer("Number of rows returned or counted"),
+			"id":        openapi.Integer(""),
+			"query":     openapi.String("Generated SQL, for debugging"),
+			"truncated": openapi.Boolean("Set when a streamed result hit the row cap"),
+		}, "success"),
+		"Error": openapi.Object(map[string]*openapi.Schema{
+			"success":       openapi.Boolean(""),
+			"error":         openapi.String("Human-readable message"),
+			"error_code":    openapi.String("Stable machine-readable code, e.g. not_found or constraint_violation"),
+			"error_details": openapi.Map(openapi.String(""), "Structured context for some error codes"),
+		}, "success", "error"),
+		"Project": openapi.Object(map[string]*openapi.Schema{
+			"id":          openapi.String(""),
+			"name":        openapi.String(""),
+			"description": openapi.String(""),
+			"created_at":  openapi.String(""),
+			"path":        openapi.String(""),
+		}, "id", "name", "created_at"),
+		"Column": openapi.Object(map[string]*openapi.Schema{
+			"name":        openapi.String(""),
+			"type":        openapi.String("Declared type"),
+			"kind":        openapi.String("Type affinity the value is validated against"),
+			"not_null":    openapi.Boolean(""),
+			"has_default": openapi.Boolean(""),
+			"primary_key": openapi.Boolean(""),
+		}),
+		"Join": openapi.Object(map[string]*openapi.Schema{
+			"type":      openapi.String("INNER, LEFT, RIGHT or FULL"),
+			"table":     openapi.String(""),
+			"condition": openapi.String("Join condition, e.g. users.id = profiles.user_id"),
+		}, "table", "condition"),
+		"ResultColumn": resultColumn,
+		"TypedResult": openapi.Object(map[string]*openapi.Schema{
+			"columns": openapi.Array(openapi.Ref("ResultColumn"), ""),
+			"rows":    openapi.Array(openapi.Array(openapi.Any(""), "Values in column order"), ""),
+		}, "columns", "rows"),
+		"Rows": {
+			Description: "Rows as objects, or a TypedResult with result_format typed",
+			OneOf: []*openapi.Schema{
+				openapi.Array(openapi.Map(openapi.Any(""), ""), ""),
+				openapi.Ref("TypedResult"),
+			},
+		},
+		"ExportRequest":    openapi.Object(exportFields, "project_id", "table"),
+		"DatabaseRequest":  actionRequestSchema(),
+		"DatabaseResponse": actionResponseSchema(),
+	}
+}
+
+// setupOpenAPI builds the document for the routes registered on srv. It panics
+// if the document is inconsistent, so mistakes surface at startup.
+func setupOpenAPI(srv *server.Server, cfg *config.Config) {
+	doc, err := buildOpenAPI(srv.Routes(), cfg)
+	if err != nil {
+		panic(err)
+	}
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		panic(fmt.Errorf("failed to encode OpenAPI document: %w", err))
+	}
+	openAPIDocument = encoded
+}
+
+// requestFields describes the JSONRequest fields actions read
+var requestFields = map[string]*openapi.Schema{
+	"project_id":          openapi.String("Project the action runs against"),
+	"project_name":        openapi.String("Name of the new project"),
+	"project_description": openapi.String("Description of the new project"),
+	"table":               openapi.String("Table name; the base table of joins"),
+	"tables":              openapi.Array(openapi.String(""), "The two tables to join"),
+	"on":                  openapi.String("Join condition, e.g. users.id = orders.user_id"),
+	"join_type":           openapi.String("Join type such as INNER or LEFT (default INNER)"),
+	"joins":               openapi.Array(openapi.Ref("Join"), "Tables joined to the base table"),
+	"data":                openapi.Map(openapi.Any(""), "Column values by column name"),
+	"schema": openapi.Map(openapi.Any(""),
+		`Column definitions by name: a type such as "INTEGER PRIMARY KEY", or an object with type, primary_key, auto_increment, not_null, unique and default`),
+	"strict":        openapi.Boolean("Validate and coerce values against the table schema, and create STRICT tables"),
+	"where":         openapi.String("SQL condition with ? placeholders"),
+	"where_args":    openapi.Array(openapi.Any(""), "Values for the placeholders in where"),
+	"columns":       openapi.Array(openapi.String(""), "Columns to return (default all)"),
+	"order_by":      openapi.String("ORDER BY clause"),
+	"group_by":      openapi.String("GROUP BY clause"),
+	"having":        openapi.String("HAVING clause"),
+	"limit":         openapi.Integer("Maximum number of rows"),
+	"offset":        openapi.Integer("Rows to skip"),
+	"stream":        openapi.Enum("Stream rows as they are read instead of buffering the result", streamJSON, streamNDJSON),
+	"result_format": openapi.Enum("Row encoding (default objects)", resultFormatObjects, resultFormatTyped),
+}
+
+// actionSchemaName returns the component name for an action, e.g.
+// "CreateTable" for create_table
+func actionSchemaName(action string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(action, "_") {
+		if word != "" {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// actionSchemas returns the request and response components of every action
+func actionSchemas() (map[string]*openapi.Schema, error) {
+	schemas := map[string]*openapi.Schema{}
+	for _, name := range actionNames() {
+		doc := actions[name].Doc
+		properties := map[string]*openapi.Schema{"action": openapi.Enum("", name)}
+		for _, field := range doc.Fields {
+			schema, ok := requestFields[field]
+			if !ok {
+				return nil, fmt.Errorf("action %s reads undocumented request field %s", name, field)
+			}
+			properties[field] = schema
+		}
+		request := openapi.Object(properties, append([]string{"action"}, doc.Required...)...)
+		request.Description = doc.Summary
+
+		component := actionSchemaName(name)
+		if _, taken := schemas[component+"Request"]; taken {
+			return nil, fmt.Errorf("action %s has the same schema name as another action", name)
+		}
+		schemas[component+"Request"] = request
+		schemas[component+"Response"] = envelope(doc.Data)
+	}
+	return schemas, nil
+}
+
+// actionRequestSchema is the /api/db request body: one of the action
+// requests, chosen by the action field
+func actionRequestSchema() *openapi.Schema {
+	schema := &openapi.Schema{Discriminator: &openapi.Discriminator{
+		PropertyName: "action",
+		Mapping:      map[string]string{},
+	}}
+	for _, name := range actionNames() {
+		ref := openapi.Ref(actionSchemaName(name) + "Request")
+		schema.OneOf = append(schema.OneOf, ref)
+		schema.Discriminator.Mapping[name] = ref.Ref
+	}
+	return schema
+}
+
+// actionResponseSchema is the /api/db response body: one of the action responses
+func actionResponseSchema() *openapi.Schema {
+	schema := &openapi.Schema{Description: "The response of the requested action"}
+	for _, name := range actionNames() {
+		schema.OneOf = append(schema.OneOf, openapi.Ref(actionSchemaName(name)+"Response"))
+	}
+	return schema
+}
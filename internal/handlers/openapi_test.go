@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/config"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/openapi"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+)
+
+// TestOpenAPIDocumentsEveryActionAndRoute fails when an action or route is
+// added without describing it in the OpenAPI document
+func TestOpenAPIDocumentsEveryActionAndRoute(t *testing.T) {
+	srv := server.NewServer()
+	SetupRoutes(srv, config.Default())
+
+	var doc openapi.Document
+	if err := json.Unmarshal(openAPIDocument, &doc); err != nil {
+		t.Fatalf("served document is not valid JSON: %v", err)
+	}
+	if err := doc.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	mapping := doc.Components.Schemas["DatabaseRequest"].Discriminator.Mapping
+	for _, name := range actionNames() {
+		component := actionSchemaName(name)
+		for _, schema := range []string{component + "Request", component + "Response"} {
+			if doc.Components.Schemas[schema] == nil {
+				t.Errorf("action %s has no %s schema", name, schema)
+			}
+		}
+		if _, ok := mapping[name]; !ok {
+			t.Errorf("action %s is missing from the DatabaseRequest discriminator", name)
+		}
+	}
+
+	for _, route := range srv.Routes() {
+		key := route.Method + " " + route.Pattern
+		if _, ok := routeDocs[key]; !ok {
+			t.Errorf("route %s is not documented in routeDocs", key)
+			continue
+		}
+		path, _ := openAPIPath(route.Pattern)
+		op := doc.Paths[path][strings.ToLower(route.Method)]
+		if op == nil {
+			t.Errorf("route %s has no operation", key)
+			continue
+		}
+		documented := false
+		for status, response := range op.Responses {
+			if status == "default" {
+				continue
+			}
+			for _, content := range response.Content {
+				documented = documented || content.Schema != nil
+			}
+		}
+		if !documented {
+			t.Errorf("route %s has no response schema", key)
+		}
+	}
+}
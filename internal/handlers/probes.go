@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/auth"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/config"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
+
+// Readiness check settings
+const (
+	readyTimeout   = 5 * time.Second // Bounds the JWKS fetch and database pings of a readiness check
+	readyPingCount = 5               // Pooled project databases pinged per readiness check
+)
+
+// Check statuses reported by /readyz
+const (
+	checkOK      = "ok"
+	checkFail    = "fail"
+	checkSkipped = "skipped"
+)
+
+// checkResult is the outcome of one readiness check
+type checkResult struct {
+	Status  string                 `json:"status"`
+	Error   string                 `json:"error,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// LivezHandler reports that the process is running and able to serve requests
+func LivezHandler(w http.ResponseWriter, r *http.Request) error {
+	return sendSuccess(w, map[string]interface{}{"status": "ok"})
+}
+
+// ReadyzHandler creates a handler reporting whether the server can serve
+// traffic. It checks that the data directory is writable and has free space,
+// that JWKS keys are available when auth is enabled, and pings a sample of the
+// pooled project databases. It responds 503 when any check fails.
+func ReadyzHandler(cfg *config.Config) func(http.ResponseWriter, *http.Request) error {
+	dataDir := cfg.Storage.DataDir
+	minFreeDisk := cfg.Storage.MinFreeDisk
+	authEnabled := cfg.Auth.Enabled
+	jwksURL := cfg.Auth.JWKSURL
+
+	return func(w http.ResponseWriter, r *http.Request) error {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+
+		checks := map[string]checkResult{
+			"data_dir":   checkDataDir(dataDir),
+			"disk_space": checkDiskSpace(dataDir, minFreeDisk),
+			"jwks":       checkJWKS(ctx, authEnabled, jwksURL),
+			"databases":  checkDatabases(ctx),
+		}
+
+		ready := true
+		for _, check := range checks {
+			if check.Status == checkFail {
+				ready = false
+			}
+		}
+
+		if !ready {
+			return sendResponse(w, http.StatusServiceUnavailable, types.JSONResponse{
+				Success:   false,
+				Error:     "Server is not ready",
+				ErrorCode: server.ErrCodeNotReady,
+				Data:      map[string]interface{}{"status": "not_ready", "checks": checks},
+			})
+		}
+		return sendSuccess(w, map[string]interface{}{"status": "ready", "checks": checks})
+	}
+}
+
+// checkDataDir verifies that files can be created in the data directory
+func checkDataDir(dataDir string) checkResult {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return checkResult{Status: checkFail, Error: err.Error()}
+	}
+	f, err := os.CreateTemp(dataDir, ".readyz-*")
+	if err != nil {
+		return checkResult{Status: checkFail, Error: err.Error()}
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return checkResult{Status: checkOK, Details: map[string]interface{}{"path": dataDir}}
+}
+
+// checkDiskSpace verifies that the data directory's filesystem has at least minFree bytes available
+func checkDiskSpace(dataDir string, minFree int64) checkResult {
+	free, err := freeDiskSpace(dataDir)
+	if err != nil {
+		return checkResult{Status: checkSkipped, Error: err.Error()}
+	}
+
+	result := checkResult{
+		Status:  checkOK,
+		Details: map[string]interface{}{"free_bytes": free, "min_free_bytes": minFree},
+	}
+	if free < uint64(minFree) {
+		result.Status = checkFail
+		result.Error = "free disk space is below storage.min_free_disk"
+	}
+	return result
+}
+
+// checkJWKS verifies that token verification keys are cached, fetching them
+// if the cache is empty or expired
+func checkJWKS(ctx context.Context, authEnabled bool, jwksURL string) checkResult {
+	if !authEnabled {
+		return checkResult{Status: checkSkipped}
+	}
+	if _, err := auth.FetchJWKS(ctx, jwksURL); err != nil {
+		return checkResult{Status: checkFail, Error: err.Error()}
+	}
+
+	keys, expiresAt := auth.JWKSCacheState()
+	return checkResult{
+		Status: checkOK,
+		Details: map[string]interface{}{
+			"keys":       keys,
+			"expires_at": expiresAt.UTC().Format(time.RFC3339),
+		},
+	}
+}
+
+// checkDatabases pings a sample of the pooled project databases
+func checkDatabases(ctx context.Context) checkResult {
+	pinged, err := database.PingProjectDBs(ctx, readyPingCount)
+	result := checkResult{Status: checkOK, Details: map[string]interface{}{"pinged": pinged}}
+	if err != nil {
+		result.Status = checkFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
+
+// sqliteHeader is the magic string every SQLite 3 database file starts with
+var sqliteHeader = []byte("SQLite format 3\x00")
+
+// DownloadProjectHandler streams a consistent copy of a project's database file
+func DownloadProjectHandler(w http.ResponseWriter, r *http.Request) error {
+	// database.Middleware has validated the project ID and checked that the
+	// project exists before opening its database
+	projectID := r.URL.Query().Get("project")
+
+	userProjectsPath, err := userProjectsDir(r)
+	if err != nil {
+		return err
+	}
+
+	db := database.GetDBFromContext(r)
+	if db == nil {
+		return server.InternalServerError("Database connection not available")
+	}
+
+	// VACUUM INTO refuses to overwrite, so reserve a unique name and free it
+	snapshot, err := os.CreateTemp(userProjectsPath, ".download-*.db")
+	if err != nil {
+		return server.InternalServerError("Failed to create snapshot file: " + err.Error())
+	}
+	snapshotPath := snapshot.Name()
+	snapshot.Close()
+	os.Remove(snapshotPath)
+	defer os.Remove(snapshotPath)
+
+	if err := db.BackupTo(snapshotPath); err != nil {
+		return database.QueryError("Failed to snapshot database", err)
+	}
+
+	file, err := os.Open(snapshotPath)
+	if err != nil {
+		return server.InternalServerError("Failed to open snapshot: " + err.Error())
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return server.InternalServerError("Failed to stat snapshot: " + err.Error())
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.db"`, exportFileName(projectID)))
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", info.Size()))
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// UploadProjectHandler creates a new project from an uploaded SQLite database file.
+// The raw file is sent as the request body; name and description come from the query string.
+func UploadProjectHandler(w http.ResponseWriter, r *http.Request) error {
+	projectName := r.URL.Query().Get("name")
+	if projectName == "" {
+		return server.BadRequest("Project name is required")
+	}
+
+	userProjectsPath, err := userProjectsDir(r)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(userProjectsPath, 0755); err != nil {
+		return server.InternalServerError("Failed to create user projects directory: " + err.Error())
+	}
+
+	upload, err := os.CreateTemp(userProjectsPath, ".upload-*.db")
+	if err != nil {
+		return server.InternalServerError("Failed to create upload file: " + err.Error())
+	}
+	uploadPath := upload.Name()
+	defer os.Remove(uploadPath)
+
+	maxUploadSize := currentLimits().MaxUploadSize
+	body := http.MaxBytesReader(w, r.Body, maxUploadSize)
+	_, err = io.Copy(upload, body)
+	upload.Close()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return server.NewHTTPError(http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("Database file exceeds the %d byte limit", maxUploadSize))
+		}
+		return server.BadRequest("Failed to read uploaded file: " + err.Error())
+	}
+
+	if err := validateDatabaseFile(uploadPath); err != nil {
+		return err
+	}
+
+	project, err := newProject(userProjectsPath, projectName, r.URL.Query().Get("description"))
+	if err != nil {
+		return err
+	}
+
+	// Link rather than rename, which would silently replace an existing database
+	if err := os.Link(uploadPath, filepath.Join(userProjectsPath, project.ID+".db")); err != nil {
+		os.RemoveAll(project.Path)
+		return server.InternalServerError("Failed to store database file: " + err.Error())
+	}
+
+	return sendSuccess(w, project)
+}
+
+// validateDatabaseFile checks that path holds an intact SQLite database
+func validateDatabaseFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return server.InternalServerError("Failed to open uploaded file: " + err.Error())
+	}
+	header := make([]byte, len(sqliteHeader))
+	_, err = io.ReadFull(file, header)
+	file.Close()
+	if err != nil || !bytes.Equal(header, sqliteHeader) {
+		return server.BadRequest("Uploaded file is not a SQLite database")
+	}
+
+	db, err := database.NewDB(database.Config{Path: path})
+	if err != nil {
+		return server.BadRequest("Failed to open uploaded database: " + err.Error())
+	}
+	defer db.Close()
+
+	problems, err := db.IntegrityCheck()
+	if err != nil {
+		return server.BadRequest("Integrity check failed: " + err.Error())
+	}
+	if len(problems) > 0 {
+		return server.BadRequest("Integrity check failed: " + strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// userProjectsDir returns the directory holding the current user's projects
+func userProjectsDir(r *http.Request) (string, error) {
+	// Get user ID from context
+	userID, ok := r.Context().Value(types.UserContextKey).(string)
+	if !ok || userID == "" {
+		return "", server.BadRequest("User context required")
+	}
+
+	// Get working directory from context
+	basePath, ok := r.Context().Value(types.WorkingDirectoryContextKey).(string)
+	if !ok || basePath == "" {
+		return "", server.InternalServerError("Working directory context required")
+	}
+
+	return filepath.Join(basePath, "projects", userID), nil
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -14,11 +18,7 @@ import (
 )
 
 // handleCreateProject creates a new project
-func handleCreateProject(w http.ResponseWriter, req types.JSONRequest, r *http.Request) error {
-	if req.ProjectName == "" {
-		return server.BadRequest("Project name is required")
-	}
-
+func handleCreateProject(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	// Get user ID from context
 	userID, ok := r.Context().Value(types.UserContextKey).(string)
 	if !ok || userID == "" {
@@ -39,41 +39,16 @@ func handleCreateProject(w http.ResponseWriter, req types.JSONRequest, r *http.R
 		return server.InternalServerError("Failed to create user projects directory: " + err.Error())
 	}
 
-	// Generate a unique project ID
-	projectID := generateProjectID()
-	projectPath := filepath.Join(userProjectsPath, projectID)
-
-	// Create project directory
-	if err := os.MkdirAll(projectPath, 0755); err != nil {
-		return server.InternalServerError("Failed to create project directory: " + err.Error())
-	}
-
-	// Create project metadata
-	project := types.Project{
-		ID:          projectID,
-		Name:        req.ProjectName,
-		Description: req.ProjectDescription,
-		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
-		Path:        projectPath,
-	}
-
-	// Save project metadata to JSON file
-	metadataPath := filepath.Join(projectPath, fmt.Sprintf("%s.json", req.ProjectName))
-	metadataFile, err := os.Create(metadataPath)
+	project, err := newProject(userProjectsPath, req.ProjectName, req.ProjectDescription)
 	if err != nil {
-		return server.InternalServerError("Failed to create project metadata file: " + err.Error())
-	}
-	defer metadataFile.Close()
-
-	if err := json.NewEncoder(metadataFile).Encode(project); err != nil {
-		return server.InternalServerError("Failed to write project metadata: " + err.Error())
+		return err
 	}
 
 	return sendSuccess(w, project)
 }
 
 // handleListProjects lists all projects for a user
-func handleListProjects(w http.ResponseWriter, req types.JSONRequest, r *http.Request) error {
+func handleListProjects(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	// Get user ID from context
 	userID, ok := r.Context().Value(types.UserContextKey).(string)
 	if !ok || userID == "" {
@@ -132,11 +107,7 @@ func handleListProjects(w http.ResponseWriter, req types.JSONRequest, r *http.Re
 }
 
 // handleDeleteProject deletes a project
-func handleDeleteProject(w http.ResponseWriter, req types.JSONRequest, r *http.Request) error {
-	if req.ProjectID == "" {
-		return server.BadRequest("Project ID is required")
-	}
-
+func handleDeleteProject(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	// Get user ID from context
 	userID, ok := r.Context().Value(types.UserContextKey).(string)
 	if !ok || userID == "" {
@@ -167,11 +138,7 @@ func handleDeleteProject(w http.ResponseWriter, req types.JSONRequest, r *http.R
 }
 
 // handleGetProject gets project information
-func handleGetProject(w http.ResponseWriter, req types.JSONRequest, r *http.Request) error {
-	if req.ProjectID == "" {
-		return server.BadRequest("Project ID is required")
-	}
-
+func handleGetProject(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	// Get user ID from context
 	userID, ok := r.Context().Value(types.UserContextKey).(string)
 	if !ok || userID == "" {
@@ -215,15 +182,10 @@ func handleGetProject(w http.ResponseWriter, req types.JSONRequest, r *http.Requ
 }
 
 // handleGetTables gets all tables for a project (requires DB connection)
-func handleGetTables(w http.ResponseWriter, req types.JSONRequest, r *http.Request) error {
-	db := database.GetDBFromContext(r)
-	if db == nil {
-		return server.InternalServerError("Database connection not available")
-	}
-
+func handleGetTables(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	tables, err := db.ListTables()
 	if err != nil {
-		return server.InternalServerError("Failed to list tables: " + err.Error())
+		return database.QueryError("Failed to list tables", err)
 	}
 
 	return sendSuccess(w, map[string]interface{}{
@@ -232,9 +194,64 @@ func handleGetTables(w http.ResponseWriter, req types.JSONRequest, r *http.Reque
 	})
 }
 
-// generateProjectID generates a unique project ID
+// newProject creates the directory and metadata file for a new project
+func newProject(userProjectsPath, name, description string) (types.Project, error) {
+	projectID, projectPath, err := reserveProjectID(userProjectsPath)
+	if err != nil {
+		return types.Project{}, server.InternalServerError("Failed to create project directory: " + err.Error())
+	}
+
+	// Create project metadata
+	project := types.Project{
+		ID:          projectID,
+		Name:        name,
+		Description: description,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+		Path:        projectPath,
+	}
+
+	// Save project metadata to JSON file, named after the ID since the
+	// project name is arbitrary user input
+	metadataPath := filepath.Join(projectPath, projectID+".json")
+	metadataFile, err := os.Create(metadataPath)
+	if err != nil {
+		return types.Project{}, server.InternalServerError("Failed to create project metadata file: " + err.Error())
+	}
+	defer metadataFile.Close()
+
+	if err := json.NewEncoder(metadataFile).Encode(project); err != nil {
+		return types.Project{}, server.InternalServerError("Failed to write project metadata: " + err.Error())
+	}
+
+	return project, nil
+}
+
+// maxProjectIDAttempts bounds the retries of reserveProjectID
+const maxProjectIDAttempts = 10
+
+// reserveProjectID generates a project ID and creates its directory. The
+// directory is created exclusively and IDs whose database file already exists
+// are skipped, so concurrent requests never share a project.
+func reserveProjectID(userProjectsPath string) (projectID, projectPath string, err error) {
+	for attempt := 0; attempt < maxProjectIDAttempts; attempt++ {
+		projectID = generateProjectID()
+		projectPath = filepath.Join(userProjectsPath, projectID)
+		if _, err := os.Stat(projectPath + ".db"); err == nil {
+			continue
+		}
+		err = os.Mkdir(projectPath, 0755)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return projectID, projectPath, err
+	}
+	return "", "", fmt.Errorf("no free project ID after %d attempts", maxProjectIDAttempts)
+}
+
+// generateProjectID generates a project ID from the current time and a
+// random suffix
 func generateProjectID() string {
-	// For now, use timestamp + random string
-	// In production, you might want to use UUID
-	return fmt.Sprintf("proj_%d", time.Now().Unix())
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("proj_%d_%s", time.Now().Unix(), hex.EncodeToString(suffix))
 }
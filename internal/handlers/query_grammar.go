@@ -0,0 +1,507 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
+
+// PostgREST-style query-string grammar for GET row endpoints:
+//
+//	select=id,name,full:name,orders(id,total),tags!inner(*)
+//	age=gte.18  name=like.A*  status=in.(new,open)  deleted_at=is.null  age=not.lt.18
+//	or=(age.lt.18,age.gt.65)  and=(name.eq.bob,or(age.eq.1,age.eq.2))
+//	order=created_at.desc.nullslast,name  limit=20  offset=40
+//
+// Embedded resources in select become joins on the foreign key between the
+// two tables (LEFT JOIN, or INNER JOIN with !inner); their columns are returned
+// as "table.column" and can be filtered and ordered by the same name. A filter
+// value without a known operator is an equality test. Everything is compiled to
+// a parameterized query through the QueryBuilder.
+
+// rowsQueryParams are list parameters that are not column filters
+var rowsQueryParams = map[string]bool{
+	"select":        true,
+	"order":         true,
+	"limit":         true,
+	"offset":        true,
+	"stream":        true,
+	"result_format": true,
+	"or":            true,
+	"and":           true,
+}
+
+// ignoredParamPrefixes mark parameters that are not column filters unless they
+// name a column, such as the "_" of cache-busters and utm_ tracking parameters
+var ignoredParamPrefixes = []string{"_", "utm_"}
+
+// ignoredParam reports whether a query parameter is not a filter on the table
+func (s *queryScope) ignoredParam(key string) bool {
+	if s.columns[s.base][key] {
+		return false
+	}
+	for _, prefix := range ignoredParamPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterOperators maps grammar operators to SQL comparison operators
+var filterOperators = map[string]string{
+	"eq":    "=",
+	"neq":   "<>",
+	"gt":    ">",
+	"gte":   ">=",
+	"lt":    "<",
+	"lte":   "<=",
+	"like":  "GLOB", // Case-sensitive, * and ? wildcards
+	"ilike": "LIKE", // Case-insensitive, * wildcard
+	"in":    "IN",
+	"is":    "IS",
+}
+
+// queryScope tracks the tables a list query may reference
+type queryScope struct {
+	base    string
+	columns map[string]map[string]bool // Known columns per table
+	joins   []types.JSONJoin
+}
+
+// parseRowsQuery turns list query-string parameters into a select request
+func parseRowsQuery(r *http.Request, db *database.DB, table string, columns []database.ColumnInfo) (types.JSONRequest, error) {
+	query := r.URL.Query()
+	limits := currentLimits()
+	req := types.JSONRequest{
+		Table:        quoteIdentifier(table),
+		Stream:       query.Get("stream"),
+		ResultFormat: query.Get("result_format"),
+		Limit:        limits.DefaultPageSize,
+	}
+	if err := resolveStreamMode(r, &req); err != nil {
+		return req, err
+	}
+	if err := resolveResultFormat(&req); err != nil {
+		return req, err
+	}
+
+	scope := &queryScope{
+		base:    table,
+		columns: map[string]map[string]bool{table: columnSet(columns)},
+	}
+
+	selected, err := scope.parseSelect(db, query.Get("select"))
+	if err != nil {
+		return req, err
+	}
+	req.Columns = selected
+	req.Joins = scope.joins
+
+	if order := query.Get("order"); order != "" {
+		if req.OrderBy, err = scope.parseOrder(order); err != nil {
+			return req, err
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return req, server.BadRequest("Invalid limit: " + limit)
+		}
+		if n > limits.MaxPageSize && req.Stream == "" {
+			return req, server.BadRequest(fmt.Sprintf("Limit must not exceed %d", limits.MaxPageSize))
+		}
+		req.Limit = n
+	}
+	if offset := query.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return req, server.BadRequest("Invalid offset: " + offset)
+		}
+		req.Offset = n
+	}
+
+	// Sort filter keys so the same URL always compiles to the same SQL
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var conditions []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			var cond string
+			var args []interface{}
+			switch {
+			case key == "or" || key == "and":
+				cond, args, err = scope.parseLogic(key, value)
+			case rowsQueryParams[key], scope.ignoredParam(key):
+				continue
+			default:
+				cond, args, err = scope.parseFilter(key, value, true)
+			}
+			if err != nil {
+				return req, err
+			}
+			conditions = append(conditions, cond)
+			req.WhereArgs = append(req.WhereArgs, args...)
+		}
+	}
+	req.Where = strings.Join(conditions, " AND ")
+
+	return req, nil
+}
+
+// parseSelect compiles the select list, registering embedded resources as joins
+func (s *queryScope) parseSelect(db *database.DB, sel string) ([]string, error) {
+	if sel == "" {
+		return []string{quoteIdentifier(s.base) + ".*"}, nil
+	}
+
+	var columns []string
+	for _, item := range splitTopLevel(sel) {
+		if item == "" {
+			continue
+		}
+
+		if open := strings.Index(item, "("); open >= 0 {
+			if !strings.HasSuffix(item, ")") {
+				return nil, server.BadRequest("Invalid embedded resource: " + item)
+			}
+			embedded, err := s.embed(db, item[:open], item[open+1:len(item)-1])
+			if err != nil {
+				return nil, err
+			}
+			columns = append(columns, embedded...)
+			continue
+		}
+
+		alias, name, hasAlias := strings.Cut(item, ":")
+		if !hasAlias {
+			name = alias
+		}
+		if name == "*" {
+			columns = append(columns, quoteIdentifier(s.base)+".*")
+			continue
+		}
+		ref, err := s.column(name)
+		if err != nil {
+			return nil, err
+		}
+		if hasAlias {
+			ref += " AS " + quoteIdentifier(alias)
+		}
+		columns = append(columns, ref)
+	}
+
+	if len(columns) == 0 {
+		return nil, server.BadRequest("Empty select list")
+	}
+	return columns, nil
+}
+
+// embed joins a related table and returns its selected columns, aliased "table.column"
+func (s *queryScope) embed(db *database.DB, spec, inner string) ([]string, error) {
+	table, hint, _ := strings.Cut(spec, "!")
+	joinType := "LEFT"
+	switch hint {
+	case "":
+	case "inner":
+		joinType = "INNER"
+	default:
+		return nil, server.BadRequest("Unknown embedding hint: " + hint)
+	}
+
+	if table == s.base {
+		return nil, server.BadRequest("Cannot embed a table in itself: " + table)
+	}
+	if _, ok := s.columns[table]; ok {
+		return nil, server.BadRequest("Table embedded more than once: " + table)
+	}
+
+	columns, err := db.TableColumns(table)
+	if errors.Is(err, database.ErrNoSuchTable) {
+		return nil, server.NotFound("Table not found: " + table).WithCode(server.ErrCodeTableNotFound)
+	}
+	if err != nil {
+		return nil, database.QueryError("Failed to read table schema", err)
+	}
+
+	condition, err := relationshipCondition(db, s.base, table)
+	if err != nil {
+		return nil, err
+	}
+	s.columns[table] = columnSet(columns)
+	s.joins = append(s.joins, types.JSONJoin{
+		Type:      joinType,
+		Table:     quoteIdentifier(table),
+		Condition: condition,
+	})
+
+	var names []string
+	if inner == "" || inner == "*" {
+		for _, col := range columns {
+			names = append(names, col.Name)
+		}
+	} else {
+		names = splitTopLevel(inner)
+	}
+
+	selected := make([]string, 0, len(names))
+	for _, name := range names {
+		if strings.ContainsAny(name, "()") {
+			return nil, server.BadRequest("Nested embedded resources are not supported: " + name)
+		}
+		ref, err := s.column(table + "." + name)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, ref+" AS "+quoteIdentifier(table+"."+name))
+	}
+	return selected, nil
+}
+
+// relationshipCondition finds the single foreign key linking two tables, in either
+// direction, and returns the matching join condition
+func relationshipCondition(db *database.DB, base, embedded string) (string, error) {
+	var candidates []database.ForeignKey
+	for _, pair := range [][2]string{{base, embedded}, {embedded, base}} {
+		keys, err := db.ForeignKeys(pair[0])
+		if err != nil {
+			return "", database.QueryError("Failed to read foreign keys", err)
+		}
+		for _, key := range keys {
+			if key.RefTable == pair[1] {
+				candidates = append(candidates, key)
+			}
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", server.BadRequest(fmt.Sprintf("No foreign key relationship between %s and %s", base, embedded))
+	case 1:
+	default:
+		return "", server.BadRequest(fmt.Sprintf("Ambiguous relationship between %s and %s", base, embedded))
+	}
+
+	key := candidates[0]
+	parts := make([]string, len(key.From))
+	for i := range key.From {
+		parts[i] = fmt.Sprintf("%s.%s = %s.%s",
+			quoteIdentifier(key.Table), quoteIdentifier(key.From[i]),
+			quoteIdentifier(key.RefTable), quoteIdentifier(key.To[i]))
+	}
+	return strings.Join(parts, " AND "), nil
+}
+
+// column resolves "name" on the base table or "table.name" on an embedded table
+func (s *queryScope) column(ref string) (string, error) {
+	table, name := s.base, ref
+	if t, n, ok := strings.Cut(ref, "."); ok {
+		if _, embedded := s.columns[t]; !embedded {
+			return "", server.BadRequest("Table is not embedded in select: " + t)
+		}
+		table, name = t, n
+	}
+	if !s.columns[table][name] {
+		return "", server.BadRequest("Unknown column: " + ref)
+	}
+	return quoteIdentifier(table) + "." + quoteIdentifier(name), nil
+}
+
+// parseOrder compiles order=col.desc.nullslast,other into an ORDER BY list
+func (s *queryScope) parseOrder(order string) (string, error) {
+	var terms []string
+	for _, term := range strings.Split(order, ",") {
+		segments := strings.Split(strings.TrimSpace(term), ".")
+		direction, nulls := "ASC", ""
+		for len(segments) > 1 {
+			last := strings.ToLower(segments[len(segments)-1])
+			switch last {
+			case "asc", "desc":
+				direction = strings.ToUpper(last)
+			case "nullsfirst":
+				nulls = " NULLS FIRST"
+			case "nullslast":
+				nulls = " NULLS LAST"
+			default:
+				goto resolved
+			}
+			segments = segments[:len(segments)-1]
+		}
+	resolved:
+		ref, err := s.column(strings.Join(segments, "."))
+		if err != nil {
+			return "", err
+		}
+		terms = append(terms, ref+" "+direction+nulls)
+	}
+	return strings.Join(terms, ", "), nil
+}
+
+// parseFilter compiles column=op.value. With bareEquality, a value that does
+// not start with an operator is compared for equality.
+func (s *queryScope) parseFilter(columnRef, expr string, bareEquality bool) (string, []interface{}, error) {
+	ref, err := s.column(columnRef)
+	if err != nil {
+		return "", nil, err
+	}
+
+	negate := false
+	if rest, ok := strings.CutPrefix(expr, "not."); ok {
+		negate, expr = true, rest
+	}
+
+	op, value, ok := strings.Cut(expr, ".")
+	sqlOp, known := filterOperators[op]
+	if !ok || !known {
+		if !bareEquality || negate {
+			return "", nil, server.BadRequest("Unknown filter operator in: " + expr)
+		}
+		return ref + " = ?", []interface{}{expr}, nil
+	}
+
+	var cond string
+	var args []interface{}
+	switch op {
+	case "in":
+		if !strings.HasPrefix(value, "(") || !strings.HasSuffix(value, ")") {
+			return "", nil, server.BadRequest("in filter expects a list like in.(a,b): " + value)
+		}
+		items := splitTopLevel(value[1 : len(value)-1])
+		placeholders := make([]string, len(items))
+		for i, item := range items {
+			placeholders[i] = "?"
+			args = append(args, unquoteValue(item))
+		}
+		cond = fmt.Sprintf("%s IN (%s)", ref, strings.Join(placeholders, ", "))
+	case "is":
+		switch strings.ToLower(value) {
+		case "null", "unknown":
+			cond = ref + " IS NULL"
+		case "true":
+			cond = ref + " IS TRUE"
+		case "false":
+			cond = ref + " IS FALSE"
+		default:
+			return "", nil, server.BadRequest("is filter expects null, true or false: " + value)
+		}
+	case "like":
+		cond = ref + " GLOB ?"
+		args = []interface{}{strings.ReplaceAll(value, "%", "*")}
+	case "ilike":
+		cond = ref + " LIKE ?"
+		args = []interface{}{strings.ReplaceAll(value, "*", "%")}
+	default:
+		cond = ref + " " + sqlOp + " ?"
+		args = []interface{}{unquoteValue(value)}
+	}
+
+	if negate {
+		cond = "NOT (" + cond + ")"
+	}
+	return cond, args, nil
+}
+
+// parseLogic compiles or=(term,term) / and=(term,term), where a term is
+// column.op.value or a nested or(...) / and(...), optionally prefixed by not.
+func (s *queryScope) parseLogic(operator, expr string) (string, []interface{}, error) {
+	if !strings.HasPrefix(expr, "(") || !strings.HasSuffix(expr, ")") {
+		return "", nil, server.BadRequest(fmt.Sprintf("%s filter expects a list like %s=(a.eq.1,b.eq.2)", operator, operator))
+	}
+
+	var conditions []string
+	var args []interface{}
+	for _, term := range splitTopLevel(expr[1 : len(expr)-1]) {
+		negate := false
+		if rest, ok := strings.CutPrefix(term, "not."); ok {
+			negate, term = true, rest
+		}
+
+		var cond string
+		var termArgs []interface{}
+		var err error
+		if nested, rest, ok := strings.Cut(term, "("); ok && (nested == "or" || nested == "and") {
+			cond, termArgs, err = s.parseLogic(nested, "("+rest)
+		} else {
+			columnRef, filter, found := splitFilterTerm(term)
+			if !found {
+				return "", nil, server.BadRequest("Invalid filter term: " + term)
+			}
+			cond, termArgs, err = s.parseFilter(columnRef, filter, false)
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		if negate {
+			cond = "NOT " + cond
+		}
+		conditions = append(conditions, cond)
+		args = append(args, termArgs...)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil, server.BadRequest(operator + " filter has no terms")
+	}
+	return "(" + strings.Join(conditions, " "+strings.ToUpper(operator)+" ") + ")", args, nil
+}
+
+// splitFilterTerm splits "table.col.op.value" at the first operator segment
+func splitFilterTerm(term string) (string, string, bool) {
+	segments := strings.Split(term, ".")
+	for i := 1; i < len(segments); i++ {
+		if _, ok := filterOperators[segments[i]]; ok || segments[i] == "not" {
+			return strings.Join(segments[:i], "."), strings.Join(segments[i:], "."), true
+		}
+	}
+	return "", "", false
+}
+
+// splitTopLevel splits on commas that are not inside parentheses or double quotes
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start, quoted := 0, 0, false
+	for i, c := range s {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// unquoteValue strips the double quotes used to protect commas and parentheses
+func unquoteValue(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// columnSet returns the set of column names of a table
+func columnSet(columns []database.ColumnInfo) map[string]bool {
+	set := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		set[col.Name] = true
+	}
+	return set
+}
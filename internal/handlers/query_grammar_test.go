@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/config"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
+
+// openGrammarDB creates a database with users and their orders
+func openGrammarDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.NewDB(database.Config{Path: filepath.Join(t.TempDir(), "grammar.db"), ForeignKeys: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, stmt := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, age INTEGER, status TEXT, deleted_at TEXT, utm_source TEXT)`,
+		`CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id), total REAL)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+// parseUsersQuery parses a query string for the users table
+func parseUsersQuery(t *testing.T, db *database.DB, query string) (types.JSONRequest, error) {
+	t.Helper()
+	columns, err := db.TableColumns("users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/rows?"+query, nil)
+	return parseRowsQuery(r, db, "users", columns)
+}
+
+func TestParseRowsQueryFilters(t *testing.T) {
+	ApplyConfig(config.Default())
+	db := openGrammarDB(t)
+
+	tests := []struct {
+		name  string
+		query string
+		where string
+		args  []interface{}
+	}{
+		{"comparison", "age=gte.18", `"users"."age" >= ?`, []interface{}{"18"}},
+		{"bare equality", "age=30", `"users"."age" = ?`, []interface{}{"30"}},
+		{"like", "name=like.A*", `"users"."name" GLOB ?`, []interface{}{"A*"}},
+		{"ilike", "name=ilike.a*", `"users"."name" LIKE ?`, []interface{}{"a%"}},
+		{"in", "status=in.(new,open)", `"users"."status" IN (?, ?)`, []interface{}{"new", "open"}},
+		{"in with quoted values", `status=in.("a,b",c)`, `"users"."status" IN (?, ?)`, []interface{}{"a,b", "c"}},
+		{"is null", "deleted_at=is.null", `"users"."deleted_at" IS NULL`, nil},
+		{"negation", "age=not.lt.18", `NOT ("users"."age" < ?)`, []interface{}{"18"}},
+		{"filters in key order", "name=bob&age=1", `"users"."age" = ? AND "users"."name" = ?`, []interface{}{"1", "bob"}},
+		{"or", "or=(age.lt.18,age.gt.65)", `("users"."age" < ? OR "users"."age" > ?)`, []interface{}{"18", "65"}},
+		{"nested and/or", "and=(name.eq.bob,or(age.eq.1,not.age.eq.2))",
+			`("users"."name" = ? AND ("users"."age" = ? OR NOT "users"."age" = ?))`, []interface{}{"bob", "1", "2"}},
+		{"ignored parameters", "_=1712345678&utm_campaign=spring", "", nil},
+		{"ignored prefix naming a column", "utm_source=ad", `"users"."utm_source" = ?`, []interface{}{"ad"}},
+		{"list parameters", "limit=5&offset=10&order=id", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := parseUsersQuery(t, db, tt.query)
+			if err != nil {
+				t.Fatalf("parse %q: %v", tt.query, err)
+			}
+			if req.Where != tt.where {
+				t.Errorf("where = %s, want %s", req.Where, tt.where)
+			}
+			if !reflect.DeepEqual(req.WhereArgs, tt.args) {
+				t.Errorf("args = %v, want %v", req.WhereArgs, tt.args)
+			}
+		})
+	}
+}
+
+func TestParseRowsQueryOrderAndPaging(t *testing.T) {
+	ApplyConfig(config.Default())
+	db := openGrammarDB(t)
+
+	req, err := parseUsersQuery(t, db, "order=age.desc.nullslast,name&limit=20&offset=40")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"users"."age" DESC NULLS LAST, "users"."name" ASC`; req.OrderBy != want {
+		t.Errorf("order by = %s, want %s", req.OrderBy, want)
+	}
+	if req.Limit != 20 || req.Offset != 40 {
+		t.Errorf("limit, offset = %d, %d, want 20, 40", req.Limit, req.Offset)
+	}
+}
+
+func TestParseRowsQueryEmbeds(t *testing.T) {
+	ApplyConfig(config.Default())
+	db := openGrammarDB(t)
+
+	tests := []struct {
+		name    string
+		query   string
+		columns []string
+		joins   []types.JSONJoin
+		where   string
+	}{
+		{
+			name:    "default select",
+			query:   "",
+			columns: []string{`"users".*`},
+		},
+		{
+			name:    "alias",
+			query:   "select=id,full:name",
+			columns: []string{`"users"."id"`, `"users"."name" AS "full"`},
+		},
+		{
+			name:    "left join",
+			query:   "select=id,orders(id,total)",
+			columns: []string{`"users"."id"`, `"orders"."id" AS "orders.id"`, `"orders"."total" AS "orders.total"`},
+			joins:   []types.JSONJoin{{Type: "LEFT", Table: `"orders"`, Condition: `"orders"."user_id" = "users"."id"`}},
+		},
+		{
+			name:    "inner join filtered on the embedded table",
+			query:   "select=name,orders!inner(total)&orders.total=gt.10",
+			columns: []string{`"users"."name"`, `"orders"."total" AS "orders.total"`},
+			joins:   []types.JSONJoin{{Type: "INNER", Table: `"orders"`, Condition: `"orders"."user_id" = "users"."id"`}},
+			where:   `"orders"."total" > ?`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := parseUsersQuery(t, db, tt.query)
+			if err != nil {
+				t.Fatalf("parse %q: %v", tt.query, err)
+			}
+			if !reflect.DeepEqual(req.Columns, tt.columns) {
+				t.Errorf("columns = %v, want %v", req.Columns, tt.columns)
+			}
+			if !reflect.DeepEqual(req.Joins, tt.joins) {
+				t.Errorf("joins = %v, want %v", req.Joins, tt.joins)
+			}
+			if req.Where != tt.where {
+				t.Errorf("where = %s, want %s", req.Where, tt.where)
+			}
+		})
+	}
+}
+
+func TestParseRowsQueryErrors(t *testing.T) {
+	ApplyConfig(config.Default())
+	db := openGrammarDB(t)
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{"unknown column", "nope=1", http.StatusBadRequest},
+		{"unknown operator after not", "age=not.foo.1", http.StatusBadRequest},
+		{"in without a list", "status=in.new", http.StatusBadRequest},
+		{"invalid is value", "deleted_at=is.maybe", http.StatusBadRequest},
+		{"or without a list", "or=age.lt.1", http.StatusBadRequest},
+		{"invalid or term", "or=(age)", http.StatusBadRequest},
+		{"order by unknown column", "order=nope.desc", http.StatusBadRequest},
+		{"filter on a table not embedded", "orders.total=gt.1", http.StatusBadRequest},
+		{"embed unknown table", "select=missing(id)", http.StatusNotFound},
+		{"embed itself", "select=users(id)", http.StatusBadRequest},
+		{"unknown embedding hint", "select=orders!outer(id)", http.StatusBadRequest},
+		{"invalid limit", "limit=0", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseUsersQuery(t, db, tt.query)
+			var httpErr server.HTTPError
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("parse %q: got %v, want an HTTP error", tt.query, err)
+			}
+			if httpErr.Code != tt.status {
+				t.Errorf("parse %q: status %d (%s), want %d", tt.query, httpErr.Code, httpErr.Message, tt.status)
+			}
+		})
+	}
+}
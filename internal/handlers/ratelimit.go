@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/config"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
+
+// rateLimiter limits /api requests; its limits are set by ApplyConfig
+var rateLimiter = server.NewRateLimiter(classifyRequest)
+
+// applyRateLimits converts the rate limit settings for the rate limiter
+func applyRateLimits(cfg config.RateLimitConfig) {
+	rateLimiter.SetLimits(server.RateLimits{
+		Enabled: cfg.Enabled,
+		PerMinute: map[server.RateClass]int{
+			server.RateRead:  cfg.Reads,
+			server.RateWrite: cfg.Writes,
+			server.RateDDL:   cfg.DDL,
+		},
+		ProjectPerMinute: map[server.RateClass]int{
+			server.RateRead:  cfg.ProjectReads,
+			server.RateWrite: cfg.ProjectWrites,
+			server.RateDDL:   cfg.ProjectDDL,
+		},
+	})
+}
+
+// classifyRequest returns the rate class and project of an /api request. JSON
+// database requests are classified by the permissions of their action, uploads
+// as DDL and other routes by method.
+func classifyRequest(r *http.Request) (server.RateClass, string) {
+	projectID := server.Param(r, "project")
+	if projectID == "" {
+		projectID = r.URL.Query().Get("project")
+	}
+
+	switch r.Pattern {
+	case "/api/projects/upload":
+		return server.RateDDL, projectID
+	case "/api/db", "/api/export":
+		if r.Method != http.MethodPost {
+			break
+		}
+		req, ok := peekJSONRequest(r)
+		if !ok {
+			break
+		}
+		if req.ProjectID != "" {
+			projectID = req.ProjectID
+		}
+		if action, ok := actions[req.Action]; ok {
+			return action.rateClass(), projectID
+		}
+		return server.RateRead, projectID
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return server.RateRead, projectID
+	}
+	return server.RateWrite, projectID
+}
+
+// peekJSONRequest decodes the request body as a JSONRequest and restores the
+// body for the handler. ok is false if the body is not a JSON object or could
+// not be read; the handler then reports the error.
+func peekJSONRequest(r *http.Request) (req types.JSONRequest, ok bool) {
+	body, err := server.PeekBody(r)
+	if err != nil {
+		return req, false
+	}
+	return req, json.Unmarshal(body, &req) == nil
+}
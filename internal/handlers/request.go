@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
+
+// decodeJSONRequest reads and decodes a JSONRequest body, strictly when
+// limits.strict_json is set. The data, schema and where_args values are
+// checked against the limits.
+func decodeJSONRequest(r *http.Request) (types.JSONRequest, error) {
+	var req types.JSONRequest
+	body, err := server.ReadBody(r)
+	if err != nil {
+		return req, err
+	}
+	if err := decodeJSON(body, &req, currentLimits().StrictJSON); err != nil {
+		return req, err
+	}
+	return req, checkRequestLimits(req)
+}
+
+// decodeJSON decodes body into v. Strict decoding rejects unknown fields and
+// anything after the JSON value.
+func decodeJSON(body []byte, v interface{}, strict bool) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return jsonError(err)
+	}
+	if strict {
+		if err := dec.Decode(&json.RawMessage{}); err != io.EOF {
+			return server.BadRequest("Invalid JSON request: unexpected data after the JSON value").
+				WithCode(server.ErrCodeInvalidJSON)
+		}
+	}
+	return nil
+}
+
+// jsonError describes a decoding failure to the client
+func jsonError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return server.BadRequest("Request body is required").WithCode(server.ErrCodeInvalidJSON)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return server.BadRequest("Invalid JSON request: unexpected end of input").WithCode(server.ErrCodeInvalidJSON)
+	case errors.As(err, &syntaxErr):
+		return server.BadRequest(fmt.Sprintf("Invalid JSON request at offset %d: %s", syntaxErr.Offset, syntaxErr.Error())).
+			WithCode(server.ErrCodeInvalidJSON)
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return server.BadRequest("Invalid JSON request: expected " + typeErr.Type.String() + ", got " + typeErr.Value).
+				WithCode(server.ErrCodeInvalidJSON)
+		}
+		return server.BadRequest(fmt.Sprintf("Invalid value for %s: expected %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)).
+			WithCode(server.ErrCodeInvalidJSON).
+			WithDetail("field", typeErr.Field)
+	}
+
+	// encoding/json has no error type for unknown fields
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		field, _ = strconv.Unquote(field)
+		return server.BadRequest("Unknown field: "+field).
+			WithCode(server.ErrCodeUnknownField).
+			WithDetail("field", field)
+	}
+	return server.BadRequest("Invalid JSON request: " + err.Error()).WithCode(server.ErrCodeInvalidJSON)
+}
+
+// checkRequestLimits rejects requests whose data, schema or where_args hold
+// more values, or more deeply nested values, than the limits allow
+func checkRequestLimits(req types.JSONRequest) error {
+	if err := checkDataLimits("data", req.Data); err != nil {
+		return err
+	}
+	if err := checkDataLimits("schema", req.Schema); err != nil {
+		return err
+	}
+
+	l := currentLimits()
+	if len(req.WhereArgs) > l.MaxWhereArgs {
+		return limitExceeded("where_args", l.MaxWhereArgs,
+			fmt.Sprintf("where_args has %d values; the limit is %d", len(req.WhereArgs), l.MaxWhereArgs))
+	}
+	if exceedsDepth(req.WhereArgs, l.MaxJSONDepth) {
+		return limitExceeded("where_args", l.MaxJSONDepth,
+			fmt.Sprintf("where_args is nested more than %d levels deep", l.MaxJSONDepth))
+	}
+	return nil
+}
+
+// checkDataLimits checks a column-to-value object against the field count and
+// depth limits
+func checkDataLimits(name string, data map[string]interface{}) error {
+	l := currentLimits()
+	if len(data) > l.MaxDataFields {
+		return limitExceeded(name, l.MaxDataFields,
+			fmt.Sprintf("%s has %d fields; the limit is %d", name, len(data), l.MaxDataFields))
+	}
+	if exceedsDepth(data, l.MaxJSONDepth) {
+		return limitExceeded(name, l.MaxJSONDepth,
+			fmt.Sprintf("%s is nested more than %d levels deep", name, l.MaxJSONDepth))
+	}
+	return nil
+}
+
+// limitExceeded creates the 400 error for a field over a request limit
+func limitExceeded(field string, limit int, message string) error {
+	return server.BadRequest(message).
+		WithCode(server.ErrCodeLimitExceeded).
+		WithDetail("field", field).
+		WithDetail("limit", strconv.Itoa(limit))
+}
+
+// exceedsDepth reports whether arrays and objects in v nest more than depth
+// levels deep, counting v itself. It stops descending once the limit is passed.
+func exceedsDepth(v interface{}, depth int) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if depth == 0 {
+			return true
+		}
+		for _, child := range v {
+			if exceedsDepth(child, depth-1) {
+				return true
+			}
+		}
+	case []interface{}:
+		if depth == 0 {
+			return true
+		}
+		for _, child := range v {
+			if exceedsDepth(child, depth-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
+
+// RESTful resource handlers for /api/projects/{project}/tables/{table}/...
+// They map onto the same database.DB CRUD methods as the /api/db actions.
+
+// ListRowsHandler lists rows with column filters, ordering, pagination and
+// embedded resources, using the query-string grammar in query_grammar.go.
+func ListRowsHandler(w http.ResponseWriter, r *http.Request) error {
+	db, table, columns, err := resourceTable(r)
+	if err != nil {
+		return err
+	}
+
+	req, err := parseRowsQuery(r, db, table, columns)
+	if err != nil {
+		return err
+	}
+
+	query, args := buildSelectQuery(req)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return database.QueryError("Failed to execute query", err)
+	}
+	defer rows.Close()
+
+	if req.Stream != "" {
+		return streamResult(w, r, req, rows)
+	}
+
+	data, count, err := rowsToResult(rows, req.ResultFormat)
+	if err != nil {
+		return database.QueryError("Failed to process results", err)
+	}
+
+	return sendResponse(w, http.StatusOK, types.JSONResponse{
+		Success: true,
+		Data:    data,
+		Count:   count,
+	})
+}
+
+// CreateRowHandler inserts the JSON object in the request body as a new row
+func CreateRowHandler(w http.ResponseWriter, r *http.Request) error {
+	db, table, columns, err := resourceTable(r)
+	if err != nil {
+		return err
+	}
+
+	data, err := decodeRowBody(r, table, columns, db)
+	if err != nil {
+		return err
+	}
+
+	id, err := db.Insert(quoteIdentifier(table), data)
+	if err != nil {
+		return database.QueryError("Failed to insert record", err)
+	}
+
+	// Point at the new row when its primary key is known
+	if location := insertedKey(db, table, columns, data, id); location != "" {
+		w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+location)
+	}
+
+	return sendResponse(w, http.StatusCreated, types.JSONResponse{
+		Success: true,
+		ID:      id,
+		Data:    map[string]interface{}{"inserted_id": id},
+	})
+}
+
+// GetRowHandler returns the row with the primary key given in the path
+func GetRowHandler(w http.ResponseWriter, r *http.Request) error {
+	db, table, columns, err := resourceTable(r)
+	if err != nil {
+		return err
+	}
+
+	where, pkValue, err := primaryKeyCondition(r, columns)
+	if err != nil {
+		return err
+	}
+
+	query, args := database.NewQueryBuilder(quoteIdentifier(table)).
+		Where(where, pkValue).
+		Limit(1).
+		Build()
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return database.QueryError("Failed to execute query", err)
+	}
+	defer rows.Close()
+
+	data, err := rowsToMap(rows)
+	if err != nil {
+		return database.QueryError("Failed to process results", err)
+	}
+	if len(data) == 0 {
+		return server.NotFound("Row not found")
+	}
+
+	return sendSuccess(w, data[0])
+}
+
+// UpdateRowHandler applies the JSON object in the request body to one row
+func UpdateRowHandler(w http.ResponseWriter, r *http.Request) error {
+	db, table, columns, err := resourceTable(r)
+	if err != nil {
+		return err
+	}
+
+	where, pkValue, err := primaryKeyCondition(r, columns)
+	if err != nil {
+		return err
+	}
+
+	data, err := decodeRowBody(r, table, columns, db)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := db.Update(quoteIdentifier(table), data, where, pkValue)
+	if err != nil {
+		return database.QueryError("Failed to update records", err)
+	}
+	if rowsAffected == 0 {
+		return server.NotFound("Row not found")
+	}
+
+	return sendSuccess(w, map[string]interface{}{"rows_affected": rowsAffected})
+}
+
+// DeleteRowHandler deletes the row with the primary key given in the path
+func DeleteRowHandler(w http.ResponseWriter, r *http.Request) error {
+	db, table, columns, err := resourceTable(r)
+	if err != nil {
+		return err
+	}
+
+	where, pkValue, err := primaryKeyCondition(r, columns)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := db.Delete(quoteIdentifier(table), where, pkValue)
+	if err != nil {
+		return database.QueryError("Failed to delete records", err)
+	}
+	if rowsAffected == 0 {
+		return server.NotFound("Row not found")
+	}
+
+	return sendSuccess(w, map[string]interface{}{"rows_affected": rowsAffected})
+}
+
+// TableSchemaHandler returns the CREATE statement and column details of a table
+func TableSchemaHandler(w http.ResponseWriter, r *http.Request) error {
+	db, table, columns, err := resourceTable(r)
+	if err != nil {
+		return err
+	}
+
+	schema, err := db.GetTableSchema(table)
+	if err != nil {
+		return database.QueryError("Failed to get table schema", err)
+	}
+
+	return sendSuccess(w, map[string]interface{}{
+		"table":   table,
+		"schema":  schema,
+		"columns": columns,
+	})
+}
+
+// resourceTable resolves the database and the {table} path parameter of a resource route
+func resourceTable(r *http.Request) (*database.DB, string, []database.ColumnInfo, error) {
+	db := database.GetDBFromContext(r)
+	if db == nil {
+		return nil, "", nil, server.InternalServerError("Database connection not available")
+	}
+
+	table := server.Param(r, "table")
+	if table == "" {
+		return nil, "", nil, server.BadRequest("Table name is required")
+	}
+
+	columns, err := db.TableColumns(table)
+	if errors.Is(err, database.ErrNoSuchTable) {
+		return nil, "", nil, server.NotFound("Table not found: " + table).WithCode(server.ErrCodeTableNotFound)
+	}
+	if err != nil {
+		return nil, "", nil, database.QueryError("Failed to read table schema", err)
+	}
+
+	return db, table, columns, nil
+}
+
+// decodeRowBody reads a row object from the request body. Unknown columns are
+// always rejected; ?strict=true also coerces values to the declared types.
+func decodeRowBody(r *http.Request, table string, columns []database.ColumnInfo, db *database.DB) (map[string]interface{}, error) {
+	body, err := server.ReadBody(r)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]interface{}
+	if err := decodeJSON(body, &data, currentLimits().StrictJSON); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, server.BadRequest("Row data is required")
+	}
+	if err := checkDataLimits("data", data); err != nil {
+		return nil, err
+	}
+
+	strict, _ := strconv.ParseBool(r.URL.Query().Get("strict"))
+	if strict {
+		if data, err = writeData(types.JSONRequest{Table: table, Data: data, Strict: true}, db); err != nil {
+			return nil, err
+		}
+	} else {
+		// Use the declared names, so the primary key is found whatever the case
+		named := make(map[string]interface{}, len(data))
+		for name, value := range data {
+			col, ok := columnNamed(columns, name)
+			if !ok {
+				return nil, server.BadRequest("Unknown column: " + name)
+			}
+			named[col.Name] = value
+		}
+		data = named
+	}
+
+	quoted := make(map[string]interface{}, len(data))
+	for name, value := range data {
+		quoted[quoteIdentifier(name)] = value
+	}
+	return quoted, nil
+}
+
+// insertedKey returns the primary key of a row inserted with data, or "" if it
+// is unknown. The rowid returned by the insert is only the key when the key
+// is the rowid or an alias of it: an INTEGER PRIMARY KEY of a rowid table.
+func insertedKey(db *database.DB, table string, columns []database.ColumnInfo, data map[string]interface{}, rowid int64) string {
+	pk, _, err := primaryKey(columns)
+	if err != nil {
+		return ""
+	}
+
+	col, _ := columnNamed(columns, pk)
+	if pk == "rowid" || strings.EqualFold(col.Type, "INTEGER") {
+		if hasRowid, err := db.HasRowid(table); err == nil && hasRowid {
+			return strconv.FormatInt(rowid, 10)
+		}
+	}
+
+	switch v := data[quoteIdentifier(pk)].(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// columnNamed finds a column by name; like SQLite, names are case-insensitive
+func columnNamed(columns []database.ColumnInfo, name string) (database.ColumnInfo, bool) {
+	for _, col := range columns {
+		if strings.EqualFold(col.Name, name) {
+			return col, true
+		}
+	}
+	return database.ColumnInfo{}, false
+}
+
+// primaryKey returns the single primary key column of a table and its kind,
+// falling back to rowid for tables without a declared primary key
+func primaryKey(columns []database.ColumnInfo) (string, string, error) {
+	var pk *database.ColumnInfo
+	for i, col := range columns {
+		if !col.PrimaryKey {
+			continue
+		}
+		if pk != nil {
+			return "", "", server.BadRequest("Tables with composite primary keys are not supported")
+		}
+		pk = &columns[i]
+	}
+
+	if pk == nil {
+		return "rowid", database.KindInteger, nil
+	}
+	return pk.Name, pk.Kind, nil
+}
+
+// primaryKeyCondition builds the WHERE clause selecting the {pk} path parameter
+func primaryKeyCondition(r *http.Request, columns []database.ColumnInfo) (string, interface{}, error) {
+	pk, kind, err := primaryKey(columns)
+	if err != nil {
+		return "", nil, err
+	}
+
+	raw := server.Param(r, "pk")
+	var value interface{} = raw
+	if kind == database.KindInteger {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return "", nil, server.BadRequest("Invalid primary key: " + raw)
+		}
+		value = n
+	}
+
+	column := pk
+	if pk != "rowid" {
+		column = quoteIdentifier(pk)
+	}
+	return column + " = ?", value, nil
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/ArnavChoudhary9/PebbleDB/internal/auth"
 	"github.com/ArnavChoudhary9/PebbleDB/internal/config"
@@ -12,22 +13,102 @@ import (
 
 // SetupRoutes configures all routes and middleware for the server
 func SetupRoutes(srv *server.Server, cfg *config.Config) {
+	ApplyConfig(cfg)
+
 	// Add global middleware
+	srv.Use(server.RequestIDMiddleware)
+	srv.Use(server.MetricsMiddleware)
 	srv.Use(server.LoggingMiddleware)
-	srv.Use(server.CORSMiddleware)
-	srv.Use(server.WorkingDirectoryMiddleware("pdb_data"))
-	srv.Use(auth.Middleware(cfg))
-	srv.Use(database.Middleware())
+	srv.Use(server.TracingMiddleware)
+	if cfg.Server.Compression {
+		srv.Use(server.CompressionMiddleware(cfg.Server.CompressionLevel, cfg.Server.CompressionMinSize))
+	}
+	srv.Use(server.RecoveryMiddleware)
+	srv.Use(cors.Middleware)
+	srv.Use(server.WorkingDirectoryMiddleware(cfg.Storage.DataDir))
 
 	// Add root routes
-	srv.GET("/", homeHandler)
+	srv.GET("/{$}", homeHandler)
+	srv.GET("/livez", LivezHandler)
+	srv.GET("/readyz", ReadyzHandler(cfg))
+	if cfg.Metrics.Enabled {
+		srv.GET("/metrics", MetricsHandler)
+	}
+	// The API description is public, so it sits outside the authenticated group
+	srv.GET("/api/openapi.json", OpenAPIHandler)
 
-	// Create API route group
+	// Create API route group; everything under /api requires authentication
 	apiGroup := srv.Group("/api")
-	apiGroup.POST("/db", DatabaseHandler)
+	apiGroup.Use(server.BodyLimitMiddleware(bodyLimit))
+	apiGroup.Use(auth.Middleware(cfg))
+	apiGroup.Use(rateLimiter.Middleware)
 	apiGroup.GET("/health", HealthHandler)
 	apiGroup.GET("/stats", statsHandler)
 	apiGroup.GET("/tables", tablesHandler)
+	apiGroup.POST("/projects/upload", UploadProjectHandler)
+
+	// Data routes get a project database connection injected
+	dataGroup := apiGroup.Group("")
+	dataGroup.Use(database.Middleware(actionNeedsDB))
+	// GET responses read from the project database are tagged with its data version
+	conditional := server.ConditionalGETMiddleware(dataETag)
+	dataGroup.POST("/db", DatabaseHandler)
+	dataGroup.GET("/export", ExportHandler, conditional)
+	dataGroup.POST("/export", ExportHandler)
+	dataGroup.GET("/projects/download", DownloadProjectHandler, conditional)
+
+	// RESTful resource routes
+	tableGroup := dataGroup.Group("/projects/{project}/tables/{table}")
+	tableGroup.GET("/schema", TableSchemaHandler, conditional)
+	tableGroup.GET("/rows", ListRowsHandler, conditional)
+	tableGroup.POST("/rows", CreateRowHandler)
+	tableGroup.GET("/rows/{pk}", GetRowHandler, conditional)
+	tableGroup.PATCH("/rows/{pk}", UpdateRowHandler)
+	tableGroup.DELETE("/rows/{pk}", DeleteRowHandler)
+
+	sealActions()
+	setupOpenAPI(srv, cfg)
+}
+
+// limits holds the request limits in effect, swapped atomically by ApplyConfig
+var limits atomic.Pointer[config.LimitsConfig]
+
+func init() {
+	limits.Store(&config.Default().Limits)
+}
+
+// ApplyConfig applies the reloadable settings used by the handlers. It is safe
+// to call while requests are being served.
+func ApplyConfig(cfg *config.Config) {
+	l := cfg.Limits
+	limits.Store(&l)
+	applyRateLimits(cfg.RateLimit)
+	applyCORS(cfg.CORS)
+}
+
+// currentLimits returns the request limits in effect
+func currentLimits() *config.LimitsConfig {
+	return limits.Load()
+}
+
+// bodyLimit returns the largest request body accepted by an /api route
+func bodyLimit(r *http.Request) int64 {
+	l := currentLimits()
+	if r.Pattern == "/api/projects/upload" {
+		return l.MaxUploadSize
+	}
+	return l.MaxBodySize
+}
+
+// dataETag returns a weak ETag for the request's project database that changes
+// whenever its data does. It is weak because compressed and uncompressed
+// responses share it.
+func dataETag(r *http.Request) string {
+	db := database.GetDBFromContext(r)
+	if db == nil {
+		return ""
+	}
+	return `W/"` + db.DataVersion() + `"`
 }
 
 // homeHandler handles the root endpoint
@@ -39,11 +120,11 @@ func homeHandler(w http.ResponseWriter, r *http.Request) error {
 // statsHandler handles database statistics requests
 func statsHandler(w http.ResponseWriter, r *http.Request) error {
 	// TODO: Implement database statistics
-	return sendError(w, "Statistics endpoint not yet implemented", http.StatusNotImplemented)
+	return server.NewHTTPError(http.StatusNotImplemented, "Statistics endpoint not yet implemented")
 }
 
 // tablesHandler handles table listing requests
 func tablesHandler(w http.ResponseWriter, r *http.Request) error {
 	// TODO: Implement table listing
-	return sendError(w, "Tables endpoint not yet implemented", http.StatusNotImplemented)
+	return server.NewHTTPError(http.StatusNotImplemented, "Tables endpoint not yet implemented")
 }
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/logging"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
+
+// Streaming modes for result sets
+const (
+	streamJSON   = "json"   // Chunked JSON envelope with a data array
+	streamNDJSON = "ndjson" // One JSON object per line, followed by a status line
+)
+
+// streamFlushInterval is the number of rows written between flushes to the client
+const streamFlushInterval = 200
+
+// resolveStreamMode validates the requested stream mode, falling back to the Accept header
+func resolveStreamMode(r *http.Request, req *types.JSONRequest) error {
+	if req.Stream == "" {
+		for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+			if err == nil && mediaType == "application/x-ndjson" {
+				req.Stream = streamNDJSON
+				break
+			}
+		}
+		return nil
+	}
+
+	req.Stream = strings.ToLower(req.Stream)
+	if req.Stream != streamJSON && req.Stream != streamNDJSON {
+		return server.BadRequest(fmt.Sprintf("Unsupported stream mode: %s", req.Stream))
+	}
+	return nil
+}
+
+// streamResult encodes rows to the client as they are scanned instead of buffering
+// them. Once the first byte is written errors can no longer change the status code,
+// so the outcome is reported in the closing envelope and the X-Stream-Status and
+// X-Stream-Error trailers.
+func streamResult(w http.ResponseWriter, r *http.Request, req types.JSONRequest, rows *database.Rows) error {
+	logger := logging.FromContext(r.Context())
+	columns, err := resultColumns(rows)
+	if err != nil {
+		return server.InternalServerError("Failed to process results: " + err.Error())
+	}
+	typed := req.ResultFormat == resultFormatTyped
+
+	contentType := "application/json"
+	if req.Stream == streamNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Trailer", "X-Stream-Status, X-Stream-Error")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	// Typed results open with the column header, as in the buffered format
+	var header []byte
+	if typed {
+		header, err = json.Marshal(columns)
+		if err != nil {
+			return nil
+		}
+	}
+	switch {
+	case req.Stream == streamJSON && typed:
+		_, err = fmt.Fprintf(w, `{"data":{"columns":%s,"rows":[`, header)
+	case req.Stream == streamJSON:
+		_, err = io.WriteString(w, `{"data":[`)
+	case typed:
+		_, err = fmt.Fprintf(w, "{\"columns\":%s}\n", header)
+	}
+	if err != nil {
+		logger.Warn("Streamed response aborted by client", "error", err)
+		return nil
+	}
+
+	// Cap the number of rows a single streamed response may return
+	maxStreamedRows := int64(currentLimits().MaxStreamedRows)
+	values, valuePtrs := scanTargets(len(columns))
+	summary := types.JSONResponse{Success: true}
+	var streamErr error
+	for rows.Next() {
+		if summary.Count >= maxStreamedRows {
+			summary.Truncated = true
+			break
+		}
+		if streamErr = rows.Scan(valuePtrs...); streamErr != nil {
+			break
+		}
+
+		if req.Stream == streamJSON && summary.Count > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				logger.Warn("Streamed response aborted by client", "rows", summary.Count, "error", err)
+				return nil
+			}
+		}
+		var row interface{}
+		if typed {
+			row = rowToSlice(columns, values)
+		} else {
+			row = rowToMap(columns, values)
+		}
+		if err := enc.Encode(row); err != nil {
+			logger.Warn("Streamed response aborted by client", "rows", summary.Count, "error", err)
+			return nil
+		}
+
+		summary.Count++
+		if flusher != nil && summary.Count%streamFlushInterval == 0 {
+			flusher.Flush()
+		}
+	}
+	if streamErr == nil {
+		streamErr = rows.Err()
+	}
+
+	if streamErr != nil {
+		logger.Error("Streamed response failed", "rows", summary.Count, "error", streamErr)
+		summary.Success = false
+		summary.Error = "Failed to process results: " + streamErr.Error()
+		summary.ErrorCode = server.StatusErrorCode(http.StatusInternalServerError)
+		w.Header().Set("X-Stream-Status", "error")
+		w.Header().Set("X-Stream-Error", summary.Error)
+	} else {
+		w.Header().Set("X-Stream-Status", "ok")
+	}
+
+	// The closing envelope carries success, count, truncated and error
+	trailer, err := json.Marshal(summary)
+	if err != nil {
+		return nil
+	}
+	if req.Stream == streamJSON {
+		// Splice the envelope fields in after the data array
+		closing := "],"
+		if typed {
+			closing = "]},"
+		}
+		trailer = append([]byte(closing), trailer[1:]...)
+	}
+	trailer = append(trailer, '\n')
+	if _, err := w.Write(trailer); err != nil {
+		logger.Warn("Streamed response aborted by client", "rows", summary.Count, "error", err)
+	}
+	return nil
+}
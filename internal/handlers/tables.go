@@ -4,60 +4,51 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
-	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
 	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
 )
 
 // handleCreateTable handles table creation from JSON schema
-func handleCreateTable(w http.ResponseWriter, req types.JSONRequest, db *database.DB) error {
-	if req.Table == "" {
-		return server.BadRequest("Table name is required")
-	}
-
+func handleCreateTable(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	var schema string
 	if req.Schema != nil {
 		schema = generateSchemaFromJSON(req.Schema)
-	} else if req.Data != nil {
-		// Auto-generate schema from sample data
-		schema = inferSchemaFromData(req.Data)
 	} else {
-		return server.BadRequest("Schema or sample data is required")
+		// Auto-generate schema from sample data
+		schema = inferSchemaFromData(req.Data, req.Strict)
 	}
 
-	err := db.CreateTable(req.Table, schema)
+	var err error
+	if req.Strict {
+		err = db.CreateStrictTable(req.Table, schema)
+	} else {
+		err = db.CreateTable(req.Table, schema)
+	}
 	if err != nil {
-		return server.InternalServerError("Failed to create table: " + err.Error())
+		return database.QueryError("Failed to create table", err)
 	}
 
 	return sendSuccess(w, map[string]string{"message": "Table created successfully"})
 }
 
 // handleDropTable handles table deletion
-func handleDropTable(w http.ResponseWriter, req types.JSONRequest, db *database.DB) error {
-	if req.Table == "" {
-		return server.BadRequest("Table name is required")
-	}
-
+func handleDropTable(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	err := db.DropTable(req.Table)
 	if err != nil {
-		return server.InternalServerError("Failed to drop table: " + err.Error())
+		return database.QueryError("Failed to drop table", err)
 	}
 
 	return sendSuccess(w, map[string]string{"message": "Table dropped successfully"})
 }
 
 // handleTableExists checks if table exists
-func handleTableExists(w http.ResponseWriter, req types.JSONRequest, db *database.DB) error {
-	if req.Table == "" {
-		return server.BadRequest("Table name is required")
-	}
-
+func handleTableExists(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	exists, err := db.TableExists(req.Table)
 	if err != nil {
-		return server.InternalServerError("Failed to check table existence: " + err.Error())
+		return database.QueryError("Failed to check table existence", err)
 	}
 
 	return sendSuccess(w, map[string]interface{}{
@@ -67,14 +58,10 @@ func handleTableExists(w http.ResponseWriter, req types.JSONRequest, db *databas
 }
 
 // handleGetSchema gets table schema
-func handleGetSchema(w http.ResponseWriter, req types.JSONRequest, db *database.DB) error {
-	if req.Table == "" {
-		return server.BadRequest("Table name is required")
-	}
-
+func handleGetSchema(w http.ResponseWriter, r *http.Request, req types.JSONRequest, db *database.DB) error {
 	schema, err := db.GetTableSchema(req.Table)
 	if err != nil {
-		return server.InternalServerError("Failed to get table schema: " + err.Error())
+		return database.QueryError("Failed to get table schema", err)
 	}
 
 	return sendSuccess(w, map[string]interface{}{
@@ -128,12 +115,62 @@ func generateSchemaFromJSON(schema map[string]interface{}) string {
 	return strings.Join(parts, ", ")
 }
 
+// strictColumnTypes are the column types STRICT tables accept
+var strictColumnTypes = map[string]bool{
+	"INT":     true,
+	"INTEGER": true,
+	"REAL":    true,
+	"TEXT":    true,
+	"BLOB":    true,
+	"ANY":     true,
+}
+
+// checkStrictSchema checks the column types of a JSON schema definition
+// against those STRICT tables accept, reporting every invalid column together
+// in a *database.ValidationError
+func checkStrictSchema(schema map[string]interface{}) *database.ValidationError {
+	var fieldErrs []database.FieldError
+	for column, def := range schema {
+		var columnType string
+		switch typeDef := def.(type) {
+		case string:
+			// The type is the first word, as in "INTEGER PRIMARY KEY"
+			if words := strings.Fields(typeDef); len(words) > 0 {
+				columnType = words[0]
+			}
+		case map[string]interface{}:
+			columnType, _ = typeDef["type"].(string)
+		default:
+			fieldErrs = append(fieldErrs, database.FieldError{Field: column, Message: "column definition must be a type or an object"})
+			continue
+		}
+
+		switch {
+		case columnType == "":
+			fieldErrs = append(fieldErrs, database.FieldError{Field: column, Message: "column type is required"})
+		case !strictColumnTypes[strings.ToUpper(columnType)]:
+			fieldErrs = append(fieldErrs, database.FieldError{Field: column,
+				Message: fmt.Sprintf("type %s is not allowed in strict tables; use INTEGER, REAL, TEXT, BLOB or ANY", columnType)})
+		}
+	}
+
+	if len(fieldErrs) > 0 {
+		sort.Slice(fieldErrs, func(i, j int) bool { return fieldErrs[i].Field < fieldErrs[j].Field })
+		return &database.ValidationError{Fields: fieldErrs}
+	}
+	return nil
+}
+
 // inferSchemaFromData infers SQL schema from sample data
-func inferSchemaFromData(data map[string]interface{}) string {
+func inferSchemaFromData(data map[string]interface{}, strict bool) string {
 	var parts []string
 
 	for column, value := range data {
 		sqlType := getSQLTypeFromValue(value)
+		// STRICT tables only accept INTEGER, REAL, TEXT, BLOB and ANY
+		if strict && sqlType == "BOOLEAN" {
+			sqlType = "INTEGER"
+		}
 		parts = append(parts, fmt.Sprintf("%s %s", column, sqlType))
 	}
 
@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// level is shared by every handler created by Configure so it can change at runtime
+var level = new(slog.LevelVar)
+
+// Configure installs the default slog logger writing to w in "text" or "json"
+// format at the given level. Output of the standard log package is routed
+// through it as well.
+func Configure(w io.Writer, levelName, format string) error {
+	if err := SetLevel(levelName); err != nil {
+		return err
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// SetLevel changes the minimum level of the default logger
+func SetLevel(name string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return fmt.Errorf("unknown log level %q", name)
+	}
+	level.Set(l)
+	return nil
+}
+
+// contextKey is the type of the request log key stored in a context
+type contextKey struct{}
+
+// requestLog holds a request's logger and the fields gathered while it is handled
+type requestLog struct {
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	fields []any
+}
+
+// NewContext returns a context carrying logger for the request. Fields added
+// with AddFields are attached to every later FromContext logger and to the
+// request's completion log line.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestLog{logger: logger})
+}
+
+// FromContext returns the request logger with all fields added so far, or the
+// default logger outside a request
+func FromContext(ctx context.Context) *slog.Logger {
+	rl, ok := ctx.Value(contextKey{}).(*requestLog)
+	if !ok {
+		return slog.Default()
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if len(rl.fields) == 0 {
+		return rl.logger
+	}
+	return rl.logger.With(rl.fields...)
+}
+
+// AddFields attaches key-value pairs such as the user or project to the
+// current request's logs. It does nothing outside a request.
+func AddFields(ctx context.Context, args ...any) {
+	rl, ok := ctx.Value(contextKey{}).(*requestLog)
+	if !ok {
+		return
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.fields = append(rl.fields, args...)
+}
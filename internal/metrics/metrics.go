@@ -0,0 +1,328 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the media type of the text exposition format written by WriteTo
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefBuckets are histogram buckets in seconds suited to request and query latencies
+var DefBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// metric is a registered metric family
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// registry holds every metric family by name
+var registry = struct {
+	sync.Mutex
+	metrics map[string]metric
+}{metrics: make(map[string]metric)}
+
+// register adds a metric family; registering a name twice is a programming error
+func register(name string, m metric) {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.metrics[name]; ok {
+		panic("metrics: duplicate metric " + name)
+	}
+	registry.metrics[name] = m
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format, sorted by name
+func WriteTo(w io.Writer) error {
+	registry.Lock()
+	names := make([]string, 0, len(registry.metrics))
+	for name := range registry.metrics {
+		names = append(names, name)
+	}
+	metrics := make([]metric, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		metrics = append(metrics, registry.metrics[name])
+	}
+	registry.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	return bw.Flush()
+}
+
+// desc describes a metric family
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+// writeHeader writes the HELP and TYPE lines
+func (d desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.typ)
+}
+
+// writeSample writes one sample line. extra is an additional label pair such as le="0.5".
+func (d desc) writeSample(w *bufio.Writer, suffix string, labelValues []string, extra string, value float64) {
+	w.WriteString(d.name + suffix)
+	if len(labelValues) > 0 || extra != "" {
+		w.WriteByte('{')
+		for i, value := range labelValues {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, d.labels[i], labelEscaper.Replace(value))
+		}
+		if extra != "" {
+			if len(labelValues) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extra)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+// labelEscaper escapes label values as the exposition format requires
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatFloat formats a sample value, spelling infinities as the format requires
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// seriesKey joins label values into a map key
+func seriesKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+// checkLabels panics when a metric is used with the wrong number of label values
+func (d desc) checkLabels(labelValues []string) {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", d.name, len(d.labels), len(labelValues)))
+	}
+}
+
+// CounterVec is a family of counters partitioned by labels
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounterVec registers a counter family with the given label names
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		desc:   desc{name: name, help: help, typ: "counter", labels: labels},
+		series: make(map[string]*counterSeries),
+	}
+	register(name, c)
+	return c
+}
+
+// Inc increments the counter for the given label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the counter for the given label values
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	c.checkLabels(labelValues)
+	key := seriesKey(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counterSeries{labelValues: append([]string(nil), labelValues...)}
+		c.series[key] = s
+	}
+	s.value += v
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(w)
+	for _, key := range sortedKeys(c.series) {
+		s := c.series[key]
+		c.writeSample(w, "", s.labelValues, "", s.value)
+	}
+}
+
+// HistogramVec is a family of histograms partitioned by labels
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64 // Per bucket, not cumulative
+	count       uint64
+	sum         float64
+}
+
+// NewHistogramVec registers a histogram family with the given upper bucket
+// bounds, in increasing order, and label names
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:    desc{name: name, help: help, typ: "histogram", labels: labels},
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+	register(name, h)
+	return h
+}
+
+// Observe records v for the given label values
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.checkLabels(labelValues)
+	key := seriesKey(labelValues)
+	bucket := sort.SearchFloat64s(h.buckets, v)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+	if bucket < len(s.counts) {
+		s.counts[bucket]++
+	}
+	s.count++
+	s.sum += v
+}
+
+// ObserveSince records the seconds elapsed since start
+func (h *HistogramVec) ObserveSince(start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(w)
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			h.writeSample(w, "_bucket", s.labelValues, `le="`+formatFloat(bound)+`"`, float64(cumulative))
+		}
+		h.writeSample(w, "_bucket", s.labelValues, `le="+Inf"`, float64(s.count))
+		h.writeSample(w, "_sum", s.labelValues, "", s.sum)
+		h.writeSample(w, "_count", s.labelValues, "", float64(s.count))
+	}
+}
+
+// Sample is one value reported by a GaugeFunc or CounterFunc
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// funcMetric reports samples computed when metrics are scraped
+type funcMetric struct {
+	desc
+	fn func() []Sample
+}
+
+// NewGaugeFunc registers a gauge family whose samples are computed by fn at scrape time
+func NewGaugeFunc(name, help string, fn func() []Sample, labels ...string) {
+	register(name, &funcMetric{desc: desc{name: name, help: help, typ: "gauge", labels: labels}, fn: fn})
+}
+
+// NewCounterFunc registers a counter family whose samples are computed by fn at
+// scrape time, for counters kept elsewhere such as sql.DBStats
+func NewCounterFunc(name, help string, fn func() []Sample, labels ...string) {
+	register(name, &funcMetric{desc: desc{name: name, help: help, typ: "counter", labels: labels}, fn: fn})
+}
+
+func (f *funcMetric) write(w *bufio.Writer) {
+	samples := f.fn()
+	sort.Slice(samples, func(i, j int) bool {
+		return seriesKey(samples[i].LabelValues) < seriesKey(samples[j].LabelValues)
+	})
+	f.writeHeader(w)
+	for _, s := range samples {
+		f.checkLabels(s.LabelValues)
+		f.writeSample(w, "", s.LabelValues, "", s.Value)
+	}
+}
+
+// sortedKeys returns the keys of a series map in order, for stable output
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Value returns a single unlabelled sample
+func Value(v float64) []Sample {
+	return []Sample{{Value: v}}
+}
+
+// startTime is when the process started, approximately
+var startTime = time.Now()
+
+// Go runtime and process metrics
+func init() {
+	NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", func() []Sample {
+		return Value(float64(runtime.NumGoroutine()))
+	})
+	NewGaugeFunc("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", func() []Sample {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return Value(float64(m.Alloc))
+	})
+	NewGaugeFunc("go_memstats_sys_bytes", "Number of bytes obtained from the system.", func() []Sample {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return Value(float64(m.Sys))
+	})
+	NewCounterFunc("go_gc_cycles_total", "Number of completed GC cycles.", func() []Sample {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return Value(float64(m.NumGC))
+	})
+	NewGaugeFunc("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", func() []Sample {
+		return Value(float64(startTime.UnixNano()) / 1e9)
+	})
+}
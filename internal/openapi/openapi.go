@@ -0,0 +1,287 @@
+// Package openapi models the parts of an OpenAPI 3.0 document that PebbleDB
+// describes itself with, and checks documents for internal consistency.
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Version is the OpenAPI version documents are written in
+const Version = "3.0.3"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag groups operations
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path, keyed by lowercase method
+type PathItem map[string]*Operation
+
+// Operation describes one method of a path
+type Operation struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Parameters  []Parameter            `json:"parameters,omitempty"`
+	RequestBody *RequestBody           `json:"requestBody,omitempty"`
+	Responses   map[string]Response    `json:"responses"`
+	Security    *[]SecurityRequirement `json:"security,omitempty"` // An empty list makes the operation public
+}
+
+// Parameter is a path, query or header parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // "path", "query" or "header"
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes an operation's request body by media type
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// Response describes a response by media type
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of one media type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds reusable schemas and security schemes
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how requests authenticate
+type SecurityScheme struct {
+	Type        string `json:"type"` // "apiKey" or "http"
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// SecurityRequirement names the security schemes an operation accepts
+type SecurityRequirement map[string][]string
+
+// Schema is a JSON Schema as used by OpenAPI 3.0
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Discriminator        *Discriminator     `json:"discriminator,omitempty"`
+}
+
+// Discriminator selects a oneOf alternative by a property value
+type Discriminator struct {
+	PropertyName string            `json:"propertyName"`
+	Mapping      map[string]string `json:"mapping,omitempty"`
+}
+
+// Ref refers to a schema in the document's components
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// String returns a string schema
+func String(description string) *Schema {
+	return &Schema{Type: "string", Description: description}
+}
+
+// Integer returns a 64-bit integer schema
+func Integer(description string) *Schema {
+	return &Schema{Type: "integer", Format: "int64", Description: description}
+}
+
+// Boolean returns a boolean schema
+func Boolean(description string) *Schema {
+	return &Schema{Type: "boolean", Description: description}
+}
+
+// Array returns an array schema of items
+func Array(items *Schema, description string) *Schema {
+	return &Schema{Type: "array", Items: items, Description: description}
+}
+
+// Map returns an object schema whose property values all match values
+func Map(values *Schema, description string) *Schema {
+	return &Schema{Type: "object", AdditionalProperties: values, Description: description}
+}
+
+// Object returns an object schema with the given properties
+func Object(properties map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: "object", Properties: properties, Required: required}
+}
+
+// Any returns a schema that accepts any value
+func Any(description string) *Schema {
+	return &Schema{Description: description}
+}
+
+// Enum returns a string schema restricted to values
+func Enum(description string, values ...string) *Schema {
+	s := String(description)
+	for _, v := range values {
+		s.Enum = append(s.Enum, v)
+	}
+	return s
+}
+
+// pathParam matches the {name} parameters of an OpenAPI path
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Validate checks that every schema reference resolves, that path parameters
+// are declared exactly as the path names them, that operation IDs are unique
+// and that every operation has a response. It returns all problems found.
+func (d *Document) Validate() error {
+	var problems []string
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	var checkSchema func(where string, s *Schema)
+	checkSchema = func(where string, s *Schema) {
+		if s == nil {
+			return
+		}
+		if s.Ref != "" {
+			name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/")
+			if _, exists := d.Components.Schemas[name]; !ok || !exists {
+				report("%s: unresolved reference %s", where, s.Ref)
+			}
+		}
+		for name, property := range s.Properties {
+			checkSchema(where+"."+name, property)
+		}
+		for _, required := range s.Required {
+			if s.Properties != nil && s.Properties[required] == nil {
+				report("%s: required property %s is not defined", where, required)
+			}
+		}
+		checkSchema(where+"[additionalProperties]", s.AdditionalProperties)
+		checkSchema(where+"[items]", s.Items)
+		for i, sub := range s.AllOf {
+			checkSchema(fmt.Sprintf("%s.allOf[%d]", where, i), sub)
+		}
+		for i, sub := range s.OneOf {
+			checkSchema(fmt.Sprintf("%s.oneOf[%d]", where, i), sub)
+		}
+		if s.Discriminator != nil {
+			for value, ref := range s.Discriminator.Mapping {
+				checkSchema(where+".discriminator."+value, &Schema{Ref: ref})
+			}
+		}
+	}
+
+	for name, s := range d.Components.Schemas {
+		checkSchema("components.schemas."+name, s)
+	}
+
+	operationIDs := map[string]string{}
+	for _, path := range sortedKeys(d.Paths) {
+		declared := map[string]bool{}
+		for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+			declared[match[1]] = true
+		}
+
+		for _, method := range sortedKeys(d.Paths[path]) {
+			op := d.Paths[path][method]
+			where := strings.ToUpper(method) + " " + path
+			if op.OperationID == "" {
+				report("%s: missing operationId", where)
+			} else if other, dup := operationIDs[op.OperationID]; dup {
+				report("%s: operationId %s is also used by %s", where, op.OperationID, other)
+			} else {
+				operationIDs[op.OperationID] = where
+			}
+			if len(op.Responses) == 0 {
+				report("%s: no responses", where)
+			}
+
+			inPath := map[string]bool{}
+			for _, param := range op.Parameters {
+				if param.In == "path" {
+					inPath[param.Name] = true
+					if !declared[param.Name] {
+						report("%s: path parameter %s is not in the path", where, param.Name)
+					}
+				}
+				checkSchema(where+" parameter "+param.Name, param.Schema)
+			}
+			for name := range declared {
+				if !inPath[name] {
+					report("%s: path parameter %s is not declared", where, name)
+				}
+			}
+
+			if op.RequestBody != nil {
+				for mediaType, content := range op.RequestBody.Content {
+					checkSchema(where+" request "+mediaType, content.Schema)
+				}
+			}
+			for status, response := range op.Responses {
+				for mediaType, content := range response.Content {
+					checkSchema(where+" response "+status+" "+mediaType, content.Schema)
+				}
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return errors.New("invalid OpenAPI document:\n  - " + strings.Join(problems, "\n  - "))
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
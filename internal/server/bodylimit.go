@@ -0,0 +1,84 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// BodyLimitMiddleware caps request bodies at limit(r) bytes. Requests that
+// declare a larger Content-Length are rejected with 413 before the body is
+// read; other bodies fail with an *http.MaxBytesError once the limit is
+// passed. A limit of zero or less leaves the body unlimited.
+func BodyLimitMiddleware(limit func(r *http.Request) int64) Middleware {
+	return func(next HTTPHandlerFunc) HTTPHandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			n := limit(r)
+			if n <= 0 || r.Body == nil || r.Body == http.NoBody {
+				return next(w, r)
+			}
+			if r.ContentLength > n {
+				return BodyTooLarge(n)
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			return next(w, r)
+		}
+	}
+}
+
+// BodyTooLarge creates the 413 error for a body over limit bytes
+func BodyTooLarge(limit int64) HTTPError {
+	return NewHTTPError(http.StatusRequestEntityTooLarge,
+		fmt.Sprintf("Request body exceeds the %d byte limit", limit)).
+		WithCode(ErrCodeBodyTooLarge).
+		WithDetail("limit", strconv.FormatInt(limit, 10))
+}
+
+// PeekBody reads the whole request body and replaces it with a copy, so the
+// handler can read it again. If reading fails, the copy returns the bytes read
+// followed by the same error, so the handler sees the failure too.
+func PeekBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	var rest io.Reader = bytes.NewReader(body)
+	if err != nil {
+		rest = io.MultiReader(rest, errorReader{err})
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{rest, r.Body}
+	return body, err
+}
+
+// errorReader fails every read with err
+type errorReader struct{ err error }
+
+func (e errorReader) Read([]byte) (int, error) { return 0, e.err }
+
+// BodyReadError converts a failure to read the request body into an HTTPError
+func BodyReadError(err error) HTTPError {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return BodyTooLarge(maxBytesErr.Limit)
+	}
+	return BadRequest("Failed to read request body: " + err.Error())
+}
+
+// ReadBody reads the whole request body, returning 413 if it is over the
+// limit set by BodyLimitMiddleware
+func ReadBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, BodyReadError(err)
+	}
+	return body, nil
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// compressor is the part of gzip.Writer and flate.Writer used for responses
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressibleTypes are the media types worth compressing, besides text/*
+// and +json or +xml types. Database downloads are already dense enough.
+var compressibleTypes = map[string]bool{
+	"application/json":       true,
+	"application/x-ndjson":   true,
+	"application/sql":        true,
+	"application/xml":        true,
+	"application/javascript": true,
+}
+
+// CompressionMiddleware compresses responses with gzip or deflate, whichever
+// the client's Accept-Encoding prefers. Responses are buffered until minSize
+// bytes are written, the handler flushes or it returns; smaller responses,
+// HEAD requests, partial content and media types that don't compress well
+// are sent as is. level is a compress/flate level.
+func CompressionMiddleware(level, minSize int) Middleware {
+	pools := map[string]*sync.Pool{
+		"gzip": {New: func() any {
+			w, _ := gzip.NewWriterLevel(io.Discard, level)
+			return w
+		}},
+		"deflate": {New: func() any {
+			w, _ := flate.NewWriter(io.Discard, level)
+			return w
+		}},
+	}
+
+	return func(next HTTPHandlerFunc) HTTPHandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				return next(w, r)
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, pool: pools[encoding], minSize: minSize}
+			defer cw.close()
+			return next(cw, r)
+		}
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header by
+// q-value, preferring gzip on ties, or returns "" if neither is acceptable
+func negotiateEncoding(header string) string {
+	q := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		weight := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				weight = parsed
+			}
+		}
+		if name != "" {
+			q[name] = weight
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range []string{"gzip", "deflate"} {
+		weight, ok := q[encoding]
+		if !ok {
+			weight, ok = q["*"]
+		}
+		if ok && weight > bestQ {
+			best, bestQ = encoding, weight
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of a response to decide whether to compress it
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	pool     *sync.Pool
+	minSize  int
+
+	status  int    // Status code held back until the decision
+	buf     []byte // Body held back until the decision
+	decided bool
+	enc     compressor // Nil if the response is not compressed
+}
+
+// WriteHeader holds the status code back until the body shows whether to compress
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.decided {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code < http.StatusOK {
+		// Informational responses go out immediately and don't end the header
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if cw.status == 0 {
+		cw.status = code
+	}
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		cw.decide(false)
+	}
+}
+
+// Write buffers the body until minSize bytes are known, then compresses it
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, b...)
+		if len(cw.buf) < cw.minSize {
+			return len(b), nil
+		}
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if cw.enc != nil {
+		return cw.enc.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush sends everything written so far, compressing it if the type allows
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if err := cw.decide(true); err != nil {
+			return
+		}
+	}
+	if cw.enc != nil {
+		if err := cw.enc.Flush(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// decide sends the header, compressed if the response qualifies, followed by
+// the buffered body. bigEnough is false when the response is known to be
+// shorter than minSize.
+func (cw *compressWriter) decide(bigEnough bool) error {
+	cw.decided = true
+	header := cw.Header()
+	if header.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		// Sniff from the uncompressed body, as net/http would have
+		header.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	if bigEnough && cw.compressible() {
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		cw.enc = cw.pool.Get().(compressor)
+		cw.enc.Reset(cw.ResponseWriter)
+	}
+
+	if cw.status != 0 {
+		cw.ResponseWriter.WriteHeader(cw.status)
+	}
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// compressible reports whether the response's status, headers and media type allow compression
+func (cw *compressWriter) compressible() bool {
+	header := cw.Header()
+	switch {
+	case cw.status == http.StatusNoContent, cw.status == http.StatusNotModified, cw.status == http.StatusPartialContent:
+		return false
+	case header.Get("Content-Encoding") != "", header.Get("Content-Range") != "":
+		return false
+	}
+	if length, err := strconv.Atoi(header.Get("Content-Length")); err == nil && length < cw.minSize {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") ||
+		compressibleTypes[mediaType]
+}
+
+// close completes the response: a short body still buffered is sent as is,
+// and a compressed one is terminated. Nothing is sent if nothing was written,
+// so an error returned by the handler can still be written. Write errors mean
+// the client is gone and are ignored, as for uncompressed responses.
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if cw.status == 0 && len(cw.buf) == 0 {
+			return
+		}
+		cw.decide(len(cw.buf) >= cw.minSize)
+	}
+	if cw.enc == nil {
+		return
+	}
+	cw.enc.Close()
+	cw.enc.Reset(io.Discard)
+	cw.pool.Put(cw.enc)
+	cw.enc = nil
+}
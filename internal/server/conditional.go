@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ConditionalGETMiddleware tags successful GET and HEAD responses with the
+// ETag returned by etag, and answers requests whose If-None-Match lists it
+// with 304 Not Modified without running the handler. The tag must change
+// whenever the response would; an empty tag leaves the request unconditional.
+// Tagged responses must be revalidated before reuse, and never by shared caches.
+func ConditionalGETMiddleware(etag func(r *http.Request) string) Middleware {
+	return func(next HTTPHandlerFunc) HTTPHandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				return next(w, r)
+			}
+			tag := etag(r)
+			if tag == "" {
+				return next(w, r)
+			}
+
+			w.Header().Set("ETag", tag)
+			w.Header().Set("Cache-Control", "private, no-cache")
+			if etagMatches(r.Header.Get("If-None-Match"), tag) {
+				w.WriteHeader(http.StatusNotModified)
+				return nil
+			}
+
+			err := next(w, r)
+			if err != nil {
+				// Error responses describe the failure, not the tagged resource
+				w.Header().Del("ETag")
+				w.Header().Del("Cache-Control")
+			}
+			return err
+		}
+	}
+}
+
+// etagMatches reports whether an If-None-Match header lists tag, using the
+// weak comparison RFC 9110 requires for If-None-Match
+func etagMatches(header, tag string) bool {
+	if header == "" {
+		return false
+	}
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// CORSPolicy describes the cross-origin requests browsers may make
+type CORSPolicy struct {
+	// AllowedOrigins lists exact origins such as "https://app.example.com",
+	// patterns with one "*" such as "https://*.example.com", or "*" for any
+	// origin. Allowed origins are echoed back.
+	AllowedOrigins   []string
+	AllowCredentials bool          // Let browsers send cookies and read credentialed responses
+	AllowedMethods   []string      // Methods allowed in preflight requests
+	AllowedHeaders   []string      // Request headers allowed in preflight requests; "*" allows any
+	ExposedHeaders   []string      // Response headers scripts may read
+	MaxAge           time.Duration // How long browsers may cache a preflight result; zero leaves it to the browser
+}
+
+// CORS applies a CORS policy, with overrides for individual routes. Policies
+// can be replaced while requests are being served.
+type CORS struct {
+	policies atomic.Pointer[corsPolicies]
+}
+
+// corsPolicies is the default policy and the per-route overrides in effect
+type corsPolicies struct {
+	base   *corsPolicy
+	routes map[string]*corsPolicy // By route pattern, e.g. "/livez"
+}
+
+// corsPolicy is a CORSPolicy prepared for matching
+type corsPolicy struct {
+	CORSPolicy
+	anyOrigin    bool
+	origins      map[string]bool
+	patterns     []originPattern
+	anyHeader    bool
+	methods      string
+	headers      string
+	exposed      string
+	maxAgeHeader string
+}
+
+// originPattern matches origins with a prefix and suffix around one wildcard
+type originPattern struct {
+	prefix, suffix string
+}
+
+// NewCORS creates a CORS middleware with the given policy
+func NewCORS(policy CORSPolicy) *CORS {
+	c := &CORS{}
+	c.SetPolicy(policy, nil)
+	return c
+}
+
+// SetPolicy replaces the default policy and the route overrides, keyed by
+// route pattern as registered, e.g. "/api/projects/{project}/tables/{table}/rows".
+// An override replaces the default policy entirely for its route.
+func (c *CORS) SetPolicy(policy CORSPolicy, routes map[string]CORSPolicy) {
+	policies := &corsPolicies{
+		base:   compileCORSPolicy(policy),
+		routes: make(map[string]*corsPolicy, len(routes)),
+	}
+	for pattern, override := range routes {
+		policies.routes[pattern] = compileCORSPolicy(override)
+	}
+	c.policies.Store(policies)
+}
+
+// compileCORSPolicy prepares a policy for matching and its header values
+func compileCORSPolicy(policy CORSPolicy) *corsPolicy {
+	p := &corsPolicy{
+		CORSPolicy: policy,
+		origins:    make(map[string]bool),
+		methods:    strings.Join(policy.AllowedMethods, ", "),
+		headers:    strings.Join(policy.AllowedHeaders, ", "),
+		exposed:    strings.Join(policy.ExposedHeaders, ", "),
+	}
+	for _, origin := range policy.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		switch {
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			p.patterns = append(p.patterns, originPattern{prefix, suffix})
+		default:
+			p.origins[origin] = true
+		}
+	}
+	for _, header := range policy.AllowedHeaders {
+		if header == "*" {
+			p.anyHeader = true
+		}
+	}
+	if policy.MaxAge > 0 {
+		p.maxAgeHeader = strconv.Itoa(int(policy.MaxAge.Seconds()))
+	}
+	return p
+}
+
+// allows reports whether origin may make requests under the policy
+func (p *corsPolicy) allows(origin string) bool {
+	origin = strings.ToLower(origin)
+	if p.anyOrigin || p.origins[origin] {
+		return true
+	}
+	for _, pattern := range p.patterns {
+		if pattern.matches(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether origin fits the pattern. The wildcard stands for one
+// or more host name characters, so "https://*.example.com" matches
+// "https://a.b.example.com" but not "https://example.com" or
+// "https://evil.com/.example.com".
+func (o originPattern) matches(origin string) bool {
+	if len(origin) <= len(o.prefix)+len(o.suffix) ||
+		!strings.HasPrefix(origin, o.prefix) || !strings.HasSuffix(origin, o.suffix) {
+		return false
+	}
+	for _, ch := range origin[len(o.prefix) : len(origin)-len(o.suffix)] {
+		if (ch < 'a' || ch > 'z') && (ch < '0' || ch > '9') && ch != '-' && ch != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// varies reports whether responses depend on the request's Origin. Only a
+// wildcard policy without credentials answers every origin the same way.
+func (p *corsPolicy) varies() bool {
+	return !p.anyOrigin || p.AllowCredentials
+}
+
+// Middleware adds CORS headers for allowed origins and answers preflight
+// requests, using the override for the request's route if there is one.
+// Requests from other origins are served without CORS headers, so browsers
+// withhold the response from the calling script.
+func (c *CORS) Middleware(next HTTPHandlerFunc) HTTPHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		policies := c.policies.Load()
+		p, ok := policies.routes[r.Pattern]
+		if !ok {
+			p = policies.base
+		}
+
+		origin := r.Header.Get("Origin")
+		header := w.Header()
+		if p.varies() {
+			header.Add("Vary", "Origin")
+		}
+
+		if r.Method == http.MethodOptions {
+			if r.Header.Get("Access-Control-Request-Method") != "" {
+				p.preflight(header, r, origin)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		}
+
+		if origin != "" && p.allows(origin) {
+			p.allowOrigin(header, origin)
+			if p.exposed != "" {
+				header.Set("Access-Control-Expose-Headers", p.exposed)
+			}
+		}
+		return next(w, r)
+	}
+}
+
+// allowOrigin sets the headers that grant origin access to the response
+func (p *corsPolicy) allowOrigin(header http.Header, origin string) {
+	if p.anyOrigin && !p.AllowCredentials {
+		header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	if p.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// preflight sets the headers answering a preflight request from origin.
+// Browsers check the requested method and headers against the lists sent.
+func (p *corsPolicy) preflight(header http.Header, r *http.Request, origin string) {
+	if p.anyHeader {
+		header.Add("Vary", "Access-Control-Request-Headers")
+	}
+	if origin == "" || !p.allows(origin) {
+		return
+	}
+	p.allowOrigin(header, origin)
+	header.Set("Access-Control-Allow-Methods", p.methods)
+	switch {
+	case p.anyHeader:
+		// A literal "*" is not honoured for credentialed requests, so echo the request
+		if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+	case p.headers != "":
+		header.Set("Access-Control-Allow-Headers", p.headers)
+	}
+	if p.maxAgeHeader != "" {
+		header.Set("Access-Control-Max-Age", p.maxAgeHeader)
+	}
+}
@@ -0,0 +1,40 @@
+package server
+
+// Error codes returned in the error_code field. Errors without a more specific
+// code use StatusErrorCode of their HTTP status, e.g. "bad_request".
+const (
+	// Constraint violations (409)
+	ErrCodeUniqueViolation     = "unique_violation"
+	ErrCodePrimaryKeyViolation = "primary_key_violation"
+	ErrCodeNotNullViolation    = "not_null_violation"
+	ErrCodeForeignKeyViolation = "foreign_key_violation"
+	ErrCodeCheckViolation      = "check_violation"
+	ErrCodeConstraintViolation = "constraint_violation"
+	ErrCodeAlreadyExists       = "already_exists" // Table or index exists
+
+	// Invalid statements (400 unless noted)
+	ErrCodeTableNotFound    = "table_not_found" // 404
+	ErrCodeColumnNotFound   = "column_not_found"
+	ErrCodeSyntaxError      = "syntax_error"
+	ErrCodeDatatypeMismatch = "datatype_mismatch"
+	ErrCodeValueTooLarge    = "value_too_large" // 413
+	ErrCodeUnknownAction    = "unknown_action"
+
+	// Malformed requests (400)
+	ErrCodeInvalidJSON   = "invalid_json"
+	ErrCodeUnknownField  = "unknown_field"  // Only with limits.strict_json
+	ErrCodeLimitExceeded = "limit_exceeded" // Too many or too deeply nested values
+
+	// Strict validation (400), with each invalid field's problem in
+	// error_details keyed by the field name
+	ErrCodeValidationFailed = "validation_failed"
+
+	// Storage conditions
+	ErrCodeDatabaseBusy = "database_busy" // 503 with Retry-After
+	ErrCodeDatabaseFull = "database_full" // 507
+	ErrCodeNotReady     = "not_ready"     // 503 from /readyz
+
+	// Request limits
+	ErrCodeRateLimited  = "rate_limited"   // 429 with Retry-After
+	ErrCodeBodyTooLarge = "body_too_large" // 413
+)
@@ -1,11 +1,23 @@
 package server
 
-import "net/http"
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/logging"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
 
 // HTTPError represents an HTTP error with status code and message
 type HTTPError struct {
-	Code    int
-	Message string
+	Code      int    // HTTP status code
+	Message   string // Human-readable message
+	ErrorCode string // Stable machine-readable code, e.g. "not_found"
+
+	Details map[string]string // Optional structured context, e.g. the violated column
+	Header  http.Header       // Optional response headers, e.g. Retry-After
 }
 
 // Error implements the error interface
@@ -13,9 +25,49 @@ func (e HTTPError) Error() string {
 	return e.Message
 }
 
-// NewHTTPError creates a new HTTP error
+// WithCode returns a copy of the error with a more specific error code
+func (e HTTPError) WithCode(errorCode string) HTTPError {
+	e.ErrorCode = errorCode
+	return e
+}
+
+// WithDetail returns a copy of the error with a detail added to error_details
+func (e HTTPError) WithDetail(key, value string) HTTPError {
+	details := make(map[string]string, len(e.Details)+1)
+	for k, v := range e.Details {
+		details[k] = v
+	}
+	details[key] = value
+	e.Details = details
+	return e
+}
+
+// WithHeader returns a copy of the error that sets a response header
+func (e HTTPError) WithHeader(key, value string) HTTPError {
+	header := e.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set(key, value)
+	e.Header = header
+	return e
+}
+
+// NewHTTPError creates a new HTTP error whose error code is derived from the
+// status, e.g. "bad_request" for 400
 func NewHTTPError(code int, message string) HTTPError {
-	return HTTPError{Code: code, Message: message}
+	return HTTPError{Code: code, Message: message, ErrorCode: StatusErrorCode(code)}
+}
+
+// StatusErrorCode returns the default error code for an HTTP status: its
+// status text in snake case
+func StatusErrorCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	text = strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text)
+	return strings.ToLower(text)
 }
 
 // Common HTTP error constructors
@@ -44,3 +96,50 @@ func Unauthorized(message string) HTTPError {
 func Forbidden(message string) HTTPError {
 	return NewHTTPError(http.StatusForbidden, message)
 }
+
+// MethodNotAllowed creates a 405 Method Not Allowed error
+func MethodNotAllowed(message string) HTTPError {
+	return NewHTTPError(http.StatusMethodNotAllowed, message)
+}
+
+// AsHTTPError converts any error into an HTTPError. Errors that are not
+// HTTPErrors become a generic 500 so internal details are not exposed.
+func AsHTTPError(err error) HTTPError {
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		if httpErr.ErrorCode == "" {
+			httpErr.ErrorCode = StatusErrorCode(httpErr.Code)
+		}
+		return httpErr
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return BodyTooLarge(maxBytesErr.Limit)
+	}
+	return InternalServerError("Internal Server Error")
+}
+
+// WriteError writes err to the client in the JSON response envelope
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	httpErr := AsHTTPError(err)
+
+	var plain HTTPError
+	if !errors.As(err, &plain) {
+		logging.FromContext(r.Context()).Error("Internal server error", "error", err)
+	}
+	logging.AddFields(r.Context(), "error_code", httpErr.ErrorCode, "error", err.Error())
+
+	for key, values := range httpErr.Header {
+		w.Header()[key] = values
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(httpErr.Code)
+	json.NewEncoder(w).Encode(types.JSONResponse{
+		Success:      false,
+		Error:        httpErr.Message,
+		ErrorCode:    httpErr.ErrorCode,
+		ErrorDetails: httpErr.Details,
+	})
+}
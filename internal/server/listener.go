@@ -0,0 +1,167 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// certCheckInterval limits how often certificate files are checked for changes
+const certCheckInterval = 5 * time.Second
+
+// ListenerConfig describes where and how the server accepts connections
+type ListenerConfig struct {
+	Addr         string // TCP address such as ":8080" or "127.0.0.1:8443"
+	UnixSocket   string // Path of a Unix domain socket; takes precedence over Addr
+	TLSCertFile  string // PEM certificate chain; enables TLS together with TLSKeyFile
+	TLSKeyFile   string // PEM private key
+	ClientCAFile string // PEM CA bundle; when set, clients must present a certificate it signed
+}
+
+// String describes the listener for log messages
+func (c ListenerConfig) String() string {
+	desc := "tcp " + c.Addr
+	if c.UnixSocket != "" {
+		desc = "unix " + c.UnixSocket
+	}
+	if c.TLSCertFile != "" {
+		desc += " (TLS"
+		if c.ClientCAFile != "" {
+			desc += ", client certificates required"
+		}
+		desc += ")"
+	}
+	return desc
+}
+
+// listen opens the network listener and, when TLS is configured, the TLS config
+func (c ListenerConfig) listen() (net.Listener, *tls.Config, error) {
+	var tlsConfig *tls.Config
+	if c.TLSCertFile != "" || c.TLSKeyFile != "" {
+		if c.TLSCertFile == "" || c.TLSKeyFile == "" {
+			return nil, nil, errors.New("both a TLS certificate and key are required")
+		}
+		reloader, err := newCertReloader(c.TLSCertFile, c.TLSKeyFile, c.ClientCAFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			GetConfigForClient: reloader.configForClient,
+		}
+	} else if c.ClientCAFile != "" {
+		return nil, nil, errors.New("client certificate verification requires TLS")
+	}
+
+	if c.UnixSocket != "" {
+		// Remove a socket left behind by a previous run
+		if info, err := os.Stat(c.UnixSocket); err == nil && info.Mode()&os.ModeSocket != 0 {
+			if err := os.Remove(c.UnixSocket); err != nil {
+				return nil, nil, fmt.Errorf("failed to remove stale socket: %w", err)
+			}
+		}
+		listener, err := net.Listen("unix", c.UnixSocket)
+		return listener, tlsConfig, err
+	}
+
+	listener, err := net.Listen("tcp", c.Addr)
+	return listener, tlsConfig, err
+}
+
+// certReloader serves the TLS certificate and client CA pool from disk,
+// reloading them when the files change
+type certReloader struct {
+	certFile, keyFile, caFile string
+
+	mu        sync.Mutex
+	config    *tls.Config
+	modTimes  [3]time.Time
+	lastCheck time.Time
+}
+
+// newCertReloader loads the certificate files, failing if they are invalid
+func newCertReloader(certFile, keyFile, caFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	modTimes, err := r.statFiles()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTimes); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// configForClient returns the current TLS config, reloading changed files first
+func (r *certReloader) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.lastCheck) >= certCheckInterval {
+		r.lastCheck = time.Now()
+		modTimes, err := r.statFiles()
+		if err == nil && modTimes != r.modTimes {
+			err = r.load(modTimes)
+			if err == nil {
+				slog.Info("Reloaded TLS certificates", "cert_file", r.certFile)
+			}
+		}
+		if err != nil {
+			// Keep serving the last good certificate
+			slog.Error("Failed to reload TLS certificates", "cert_file", r.certFile, "error", err)
+		}
+	}
+	return r.config, nil
+}
+
+// statFiles returns the modification times of the certificate, key and CA files
+func (r *certReloader) statFiles() ([3]time.Time, error) {
+	var times [3]time.Time
+	for i, path := range []string{r.certFile, r.keyFile, r.caFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return times, err
+		}
+		times[i] = info.ModTime()
+	}
+	return times, nil
+}
+
+// load reads the files and builds a new TLS config from them
+func (r *certReloader) load(modTimes [3]time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA file %s", r.caFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	r.config = config
+	r.modTimes = modTimes
+	return nil
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/metrics"
+)
+
+// HTTP request metrics, labelled by route pattern rather than path to bound cardinality
+var (
+	httpRequests = metrics.NewCounterVec("pebbledb_http_requests_total",
+		"HTTP requests handled, by method, route pattern and status code.",
+		"method", "route", "status")
+	httpRequestDuration = metrics.NewHistogramVec("pebbledb_http_request_duration_seconds",
+		"HTTP request latency in seconds, by method, route pattern and status code.",
+		metrics.DefBuckets, "method", "route", "status")
+)
+
+// MetricsMiddleware records the count and latency of every request
+func MetricsMiddleware(next HTTPHandlerFunc) HTTPHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w}
+
+		err := next(rec, r)
+
+		status := rec.status
+		if err != nil {
+			status = AsHTTPError(err).Code
+		}
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		labels := []string{metricMethod(r.Method), r.Pattern, strconv.Itoa(status)}
+		httpRequests.Inc(labels...)
+		httpRequestDuration.ObserveSince(start, labels...)
+		return err
+	}
+}
+
+// metricMethod returns the method label, folding nonstandard methods into one
+// so clients cannot create unbounded series
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "OTHER"
+}
@@ -2,37 +2,168 @@ package server
 
 import (
 	"context"
-	"log"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
+	"time"
 
+	"github.com/ArnavChoudhary9/PebbleDB/internal/logging"
 	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
 )
 
-// LoggingMiddleware logs HTTP requests
-func LoggingMiddleware(next HTTPHandlerFunc) HTTPHandlerFunc {
+// maxRequestIDLength bounds client-supplied X-Request-ID values
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware assigns every request an ID, reusing a well-formed
+// X-Request-ID header from the client, and echoes it in the response
+func RequestIDMiddleware(next HTTPHandlerFunc) HTTPHandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		log.Printf("[%s] %s %s\n", r.Method, r.URL.Path, r.RemoteAddr)
-		return next(w, r)
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set("X-Request-ID", id)
+		ctx := context.WithValue(r.Context(), types.RequestIDContextKey, id)
+		return next(w, r.WithContext(ctx))
 	}
 }
 
-// CORSMiddleware adds CORS headers
-func CORSMiddleware(next HTTPHandlerFunc) HTTPHandlerFunc {
+// RequestID returns the ID assigned by RequestIDMiddleware, or "" without it
+func RequestID(r *http.Request) string {
+	id, _ := r.Context().Value(types.RequestIDContextKey).(string)
+	return id
+}
+
+// validRequestID reports whether a client-supplied request ID is safe to log and echo
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 128-bit hex ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// LoggingMiddleware gives each request a logger carrying its request ID and
+// logs one line when the request completes, with the status, latency, bytes
+// written and any fields added by later middleware (user, project, action)
+func LoggingMiddleware(next HTTPHandlerFunc) HTTPHandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) error {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		start := time.Now()
+		logger := slog.Default().With("request_id", RequestID(r))
+		ctx := logging.NewContext(r.Context(), logger)
+		rec := &responseRecorder{ResponseWriter: w}
+
+		err := next(rec, r.WithContext(ctx))
 
-		// Handle preflight requests
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return nil
+		// Errors returned here are written by the server after this middleware
+		status := rec.status
+		if err != nil {
+			status = AsHTTPError(err).Code
+		}
+		if status == 0 {
+			status = http.StatusOK
 		}
 
-		return next(w, r)
+		attrs := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"bytes", rec.bytes,
+			"remote_addr", r.RemoteAddr,
+		}
+		level := slog.LevelInfo
+		if err != nil {
+			attrs = append(attrs, "error_code", AsHTTPError(err).ErrorCode, "error", err.Error())
+		}
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logging.FromContext(ctx).Log(ctx, level, "request completed", attrs...)
+		return err
 	}
 }
 
+// RecoveryMiddleware turns a panic in a later handler into a 500 error
+// response and logs the panic with its stack trace. Register it after
+// LoggingMiddleware so the failed request is still logged.
+func RecoveryMiddleware(next HTTPHandlerFunc) HTTPHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) (err error) {
+		rec := &responseRecorder{ResponseWriter: w}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			logging.FromContext(r.Context()).Error("Panic in handler",
+				"panic", fmt.Sprint(p), "stack", string(debug.Stack()))
+			if rec.status != 0 {
+				// The response has started; abort it so the client sees a failure
+				panic(http.ErrAbortHandler)
+			}
+			err = InternalServerError("Internal Server Error")
+		}()
+
+		return next(rec, r)
+	}
+}
+
+// responseRecorder captures the status code and body size of a response
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader records the status code
+func (rec *responseRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the number of bytes written
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+// Flush forwards to the underlying writer so streamed responses keep working
+func (rec *responseRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
 // WorkingDirectoryMiddleware adds working directory to request context
 func WorkingDirectoryMiddleware(basePath string) func(HTTPHandlerFunc) HTTPHandlerFunc {
 	return func(next HTTPHandlerFunc) HTTPHandlerFunc {
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/metrics"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
+
+// RateClass groups requests that share a rate limit
+type RateClass string
+
+// Rate classes
+const (
+	RateRead  RateClass = "read"  // Queries and metadata lookups
+	RateWrite RateClass = "write" // Row inserts, updates and deletes
+	RateDDL   RateClass = "ddl"   // Schema and project changes
+)
+
+// RateLimits configures a RateLimiter. Each class allows PerMinute[class]
+// requests per minute, with bursts up to the same number, separately for every
+// user and every client IP, and ProjectPerMinute[class] for every project.
+// Classes without a positive limit are not limited.
+type RateLimits struct {
+	Enabled          bool
+	PerMinute        map[RateClass]int
+	ProjectPerMinute map[RateClass]int
+}
+
+// RateClassifier returns the class of a request and the project it targets, if any
+type RateClassifier func(r *http.Request) (class RateClass, projectID string)
+
+// rateLimitCleanupInterval is how often idle buckets are removed
+const rateLimitCleanupInterval = time.Minute
+
+// rateLimited counts rejected requests by class
+var rateLimited = metrics.NewCounterVec("pebbledb_rate_limited_total",
+	"Requests rejected by the rate limiter, by rate class.",
+	"class")
+
+// RateLimiter limits requests with in-memory token buckets
+type RateLimiter struct {
+	classify RateClassifier
+	limits   atomic.Pointer[RateLimits]
+
+	mu          sync.Mutex
+	buckets     map[string]*tokenBucket
+	lastCleanup time.Time
+}
+
+// tokenBucket holds up to limit tokens, refilled at limit per minute
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter creates a rate limiter that is disabled until SetLimits is called
+func NewRateLimiter(classify RateClassifier) *RateLimiter {
+	l := &RateLimiter{
+		classify:    classify,
+		buckets:     make(map[string]*tokenBucket),
+		lastCleanup: time.Now(),
+	}
+	l.limits.Store(&RateLimits{})
+	return l
+}
+
+// SetLimits replaces the limits in effect. It is safe to call while requests
+// are being served; existing buckets keep their tokens, capped at the new limit.
+func (l *RateLimiter) SetLimits(limits RateLimits) {
+	l.limits.Store(&limits)
+}
+
+// Middleware rejects requests over the limit with 429 and a Retry-After
+// header. Limited requests carry RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers describing the most exhausted bucket. It must run
+// after authentication so the user is known.
+func (l *RateLimiter) Middleware(next HTTPHandlerFunc) HTTPHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		limits := l.limits.Load()
+		if !limits.Enabled {
+			return next(w, r)
+		}
+		class, projectID := l.classify(r)
+
+		// Every request is counted against its user, its project and its
+		// client IP. Requests over a Unix socket have no client IP.
+		var keys []rateKey
+		if limit := limits.PerMinute[class]; limit > 0 {
+			if ip := clientIP(r); ip != "" {
+				keys = append(keys, rateKey{"ip:" + ip, limit})
+			}
+		}
+		if user := r.Context().Value(types.UserContextKey); user != nil {
+			userKey := fmt.Sprint(user)
+			if limit := limits.PerMinute[class]; limit > 0 {
+				keys = append(keys, rateKey{"user:" + userKey, limit})
+			}
+			// Project IDs are only unique per user
+			if limit := limits.ProjectPerMinute[class]; limit > 0 && projectID != "" {
+				keys = append(keys, rateKey{"project:" + userKey + "/" + projectID, limit})
+			}
+		}
+		if len(keys) == 0 {
+			return next(w, r)
+		}
+
+		allowed, limit, remaining, reset, retryAfter := l.take(class, keys)
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+		w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=60", limit))
+		if !allowed {
+			rateLimited.Inc(string(class))
+			return NewHTTPError(http.StatusTooManyRequests, "Rate limit exceeded for "+string(class)+" requests").
+				WithCode(ErrCodeRateLimited).
+				WithHeader("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+		}
+		return next(w, r)
+	}
+}
+
+// rateKey is a bucket key and the per-minute limit of its bucket
+type rateKey struct {
+	key   string
+	limit int
+}
+
+// take takes a token from the bucket of every key for class if all of them
+// have one. It returns the limit and tokens left of the emptiest bucket, the
+// time until that bucket is full again and, when not allowed, the time until
+// all buckets have a token.
+func (l *RateLimiter) take(class RateClass, keys []rateKey) (allowed bool, limit, remaining int, reset, retryAfter time.Duration) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cleanup(now)
+
+	buckets := make([]*tokenBucket, len(keys))
+	var emptiest *tokenBucket
+	allowed = true
+	for i, k := range keys {
+		capacity := float64(k.limit)
+		perSecond := capacity / 60
+
+		key := string(class) + "|" + k.key
+		b, ok := l.buckets[key]
+		if !ok {
+			b = &tokenBucket{tokens: capacity, updated: now}
+			l.buckets[key] = b
+		}
+		b.tokens = math.Min(capacity, b.tokens+now.Sub(b.updated).Seconds()*perSecond)
+		b.updated = now
+		buckets[i] = b
+
+		if b.tokens < 1 {
+			allowed = false
+			if wait := secondsDuration((1 - b.tokens) / perSecond); wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+		if emptiest == nil || b.tokens < emptiest.tokens {
+			emptiest, limit = b, k.limit
+		}
+	}
+
+	if allowed {
+		for _, b := range buckets {
+			b.tokens--
+		}
+	}
+	capacity := float64(limit)
+	return allowed, limit, int(math.Max(0, emptiest.tokens)), secondsDuration((capacity - emptiest.tokens) / (capacity / 60)), retryAfter
+}
+
+// cleanup periodically drops buckets that have refilled completely, since a
+// new bucket starts full anyway. Callers must hold l.mu.
+func (l *RateLimiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < rateLimitCleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+
+	// A bucket is full after a minute without requests, whatever its limit
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// clientIP returns the host part of the request's remote address, or "" if
+// the address is not host:port, as on a Unix socket
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// secondsDuration converts fractional seconds to a duration
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// ceilSeconds rounds a duration up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
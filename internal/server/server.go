@@ -1,9 +1,15 @@
 package server
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // HTTPHandlerFunc is a handler function that can return an error
@@ -14,16 +20,29 @@ type Middleware func(HTTPHandlerFunc) HTTPHandlerFunc
 
 // Route represents a single route
 type Route struct {
-	Method  string
-	Pattern string
-	Handler HTTPHandlerFunc
+	Method      string
+	Pattern     string
+	Handler     HTTPHandlerFunc
+	Middlewares []Middleware // Route-specific middleware, including inherited group middleware
 }
 
-// Server represents the HTTP server with routing capabilities
+// Server represents the HTTP server with routing capabilities.
+// Patterns use http.ServeMux syntax: "{name}" captures a single path segment,
+// "{name...}" captures the rest of the path and "{$}" anchors the end of the
+// path. Captured values are read with Param.
+//
+// Middleware runs outermost first: global middleware registered with Use,
+// then group middleware from the outermost group inwards, then the
+// middleware passed when registering the route, then the handler.
 type Server struct {
 	routes      map[string][]Route // Group routes by pattern
 	middlewares []Middleware
 	mux         *http.ServeMux
+
+	mu         sync.Mutex
+	httpServer *http.Server // Set by Start, used by Shutdown
+
+	running atomic.Int64 // Requests being handled, for WaitForHandlers
 }
 
 // NewServer creates a new server instance
@@ -35,44 +54,67 @@ func NewServer() *Server {
 	}
 }
 
-// Use adds middleware to the server
+// Use adds middleware to the server. Global middleware runs for every request,
+// including requests whose method has no matching route.
 func (s *Server) Use(middleware Middleware) {
 	s.middlewares = append(s.middlewares, middleware)
 }
 
 // addRoute adds a route to the routes map
-func (s *Server) addRoute(method, pattern string, handler HTTPHandlerFunc) {
+func (s *Server) addRoute(method, pattern string, handler HTTPHandlerFunc, middlewares []Middleware) {
 	route := Route{
-		Method:  method,
-		Pattern: pattern,
-		Handler: handler,
+		Method:      method,
+		Pattern:     pattern,
+		Handler:     handler,
+		Middlewares: middlewares,
 	}
 	s.routes[pattern] = append(s.routes[pattern], route)
 }
 
 // Handle adds a route with any HTTP method
-func (s *Server) Handle(pattern string, handler HTTPHandlerFunc) {
-	s.addRoute("", pattern, handler)
+func (s *Server) Handle(pattern string, handler HTTPHandlerFunc, middlewares ...Middleware) {
+	s.addRoute("", pattern, handler, middlewares)
 }
 
 // GET adds a GET route
-func (s *Server) GET(pattern string, handler HTTPHandlerFunc) {
-	s.addRoute("GET", pattern, handler)
+func (s *Server) GET(pattern string, handler HTTPHandlerFunc, middlewares ...Middleware) {
+	s.addRoute("GET", pattern, handler, middlewares)
 }
 
 // POST adds a POST route
-func (s *Server) POST(pattern string, handler HTTPHandlerFunc) {
-	s.addRoute("POST", pattern, handler)
+func (s *Server) POST(pattern string, handler HTTPHandlerFunc, middlewares ...Middleware) {
+	s.addRoute("POST", pattern, handler, middlewares)
 }
 
 // PUT adds a PUT route
-func (s *Server) PUT(pattern string, handler HTTPHandlerFunc) {
-	s.addRoute("PUT", pattern, handler)
+func (s *Server) PUT(pattern string, handler HTTPHandlerFunc, middlewares ...Middleware) {
+	s.addRoute("PUT", pattern, handler, middlewares)
+}
+
+// PATCH adds a PATCH route
+func (s *Server) PATCH(pattern string, handler HTTPHandlerFunc, middlewares ...Middleware) {
+	s.addRoute("PATCH", pattern, handler, middlewares)
 }
 
 // DELETE adds a DELETE route
-func (s *Server) DELETE(pattern string, handler HTTPHandlerFunc) {
-	s.addRoute("DELETE", pattern, handler)
+func (s *Server) DELETE(pattern string, handler HTTPHandlerFunc, middlewares ...Middleware) {
+	s.addRoute("DELETE", pattern, handler, middlewares)
+}
+
+// Routes returns the registered routes ordered by pattern, in registration
+// order within a pattern
+func (s *Server) Routes() []Route {
+	patterns := make([]string, 0, len(s.routes))
+	for pattern := range s.routes {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	var routes []Route
+	for _, pattern := range patterns {
+		routes = append(routes, s.routes[pattern]...)
+	}
+	return routes
 }
 
 // Group creates a route group with common prefix
@@ -83,9 +125,25 @@ func (s *Server) Group(prefix string) *RouteGroup {
 	}
 }
 
-// Start starts the server with all registered routes and middleware
+// Param returns the value of a named path parameter captured by the route pattern,
+// or "" if the pattern has no such parameter
+func Param(r *http.Request, name string) string {
+	return r.PathValue(name)
+}
+
+// Start starts the server on a TCP address such as ":8080". It blocks until the
+// server fails or is stopped with Shutdown, in which case it returns nil.
 func (s *Server) Start(port string) error {
-	// Register each unique pattern once with a method dispatcher
+	return s.Listen(ListenerConfig{Addr: port})
+}
+
+// Listen starts the server with all registered routes and middleware on the
+// listener described by cfg. It blocks until the server fails or is stopped
+// with Shutdown, in which case it returns nil.
+func (s *Server) Listen(cfg ListenerConfig) error {
+	// Register each unique pattern once with a method dispatcher. Methods are
+	// matched by the dispatcher rather than by "METHOD /path" mux patterns so
+	// that middleware (e.g. CORS preflight) still runs for unmatched methods.
 	for pattern, routes := range s.routes {
 		httpHandler := s.createMethodDispatcher(routes)
 
@@ -95,88 +153,214 @@ func (s *Server) Start(port string) error {
 			return nil
 		}
 
-		// Apply middleware in reverse order
-		for i := len(s.middlewares) - 1; i >= 0; i-- {
-			handler = s.middlewares[i](handler)
-		}
+		s.mux.HandleFunc(pattern, s.finalHandler(handler))
+	}
 
-		// Convert back to http.HandlerFunc for registration
-		finalHandler := func(w http.ResponseWriter, r *http.Request) {
-			if err := handler(w, r); err != nil {
-				s.handleError(w, err)
-			}
-		}
+	// Unmatched paths get a JSON 404 through the global middleware
+	if _, ok := s.routes["/"]; !ok {
+		s.mux.HandleFunc("/", s.finalHandler(func(w http.ResponseWriter, r *http.Request) error {
+			return NotFound("Not found: " + r.URL.Path)
+		}))
+	}
+
+	listener, tlsConfig, err := cfg.listen()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.httpServer = &http.Server{
+		Handler:           s.mux,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	httpServer := s.httpServer
+	s.mu.Unlock()
+
+	slog.Info("Server listening", "listener", cfg.String())
+	if tlsConfig != nil {
+		// Certificates come from TLSConfig, so no files are passed here
+		err = httpServer.ServeTLS(listener, "", "")
+	} else {
+		err = httpServer.Serve(listener)
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting new connections and waits for in-flight requests to
+// finish. If ctx expires first, remaining connections are closed forcibly and
+// the context error is returned; their handlers may still be running, see
+// WaitForHandlers.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	httpServer := s.httpServer
+	s.mu.Unlock()
+	if httpServer == nil {
+		return nil
+	}
 
-		s.mux.HandleFunc(pattern, finalHandler)
+	err := httpServer.Shutdown(ctx)
+	if err != nil {
+		httpServer.Close()
 	}
+	return err
+}
+
+// handlerPollInterval is how often WaitForHandlers checks for running handlers
+const handlerPollInterval = 10 * time.Millisecond
 
-	log.Printf("Server starting on port %s\n", port)
-	return http.ListenAndServe(port, s.mux)
+// WaitForHandlers waits until no request handler is running, or returns the
+// context error if ctx is done first. After a forced Shutdown the requests of
+// handlers still running are cancelled, but the handlers return only once
+// they notice.
+func (s *Server) WaitForHandlers(ctx context.Context) error {
+	ticker := time.NewTicker(handlerPollInterval)
+	defer ticker.Stop()
+	for s.running.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// finalHandler applies the global middleware to handler and writes any
+// error it returns
+func (s *Server) finalHandler(handler HTTPHandlerFunc) http.HandlerFunc {
+	handler = chain(handler, s.middlewares)
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.running.Add(1)
+		defer s.running.Add(-1)
+
+		if err := handler(w, r); err != nil {
+			s.handleError(w, r, err)
+		}
+	}
 }
 
 // createMethodDispatcher creates a handler that dispatches based on HTTP method
 func (s *Server) createMethodDispatcher(routes []Route) http.HandlerFunc {
+	// Wrap each route's handler in its own middleware once, up front
+	wrapped := make([]Route, len(routes))
+	for i, route := range routes {
+		route.Handler = chain(route.Handler, route.Middlewares)
+		wrapped[i] = route
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Find matching route for the HTTP method
-		for _, route := range routes {
-			if route.Method == "" || route.Method == r.Method {
-				if err := route.Handler(w, r); err != nil {
-					s.handleError(w, err)
-				}
-				return
+		if route, ok := matchMethod(wrapped, r.Method); ok {
+			if err := route.Handler(w, r); err != nil {
+				s.handleError(w, r, err)
 			}
+			return
 		}
 
 		// If no route matches, return method not allowed
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		allowed := make([]string, 0, len(routes))
+		for _, route := range routes {
+			allowed = append(allowed, route.Method)
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		s.handleError(w, r, MethodNotAllowed("Method not allowed"))
 	}
 }
 
-// handleError handles errors returned by handlers
-func (s *Server) handleError(w http.ResponseWriter, err error) {
-	if httpErr, ok := err.(HTTPError); ok {
-		http.Error(w, httpErr.Message, httpErr.Code)
-	} else {
-		// Handle regular errors as internal server errors
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		log.Printf("Internal server error: %v", err)
+// chain wraps handler in middlewares so that the first middleware runs outermost
+func chain(handler HTTPHandlerFunc, middlewares []Middleware) HTTPHandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// matchMethod picks the route for a request method. An exact method match wins
+// over a method-less route, and GET routes also serve HEAD requests.
+func matchMethod(routes []Route, method string) (Route, bool) {
+	var fallback *Route
+	for i, route := range routes {
+		switch {
+		case route.Method == method:
+			return route, true
+		case route.Method == "" && fallback == nil:
+			fallback = &routes[i]
+		case route.Method == "GET" && method == "HEAD" && fallback == nil:
+			fallback = &routes[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
 	}
+	return Route{}, false
+}
+
+// handleError handles errors returned by handlers
+func (s *Server) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	WriteError(w, r, err)
 }
 
-// RouteGroup represents a group of routes with common prefix
+// RouteGroup represents a group of routes with common prefix and middleware
 type RouteGroup struct {
-	server *Server
-	prefix string
+	server      *Server
+	prefix      string
+	middlewares []Middleware
+}
+
+// Use adds middleware that runs for every route registered on the group
+// afterwards, including routes of nested groups
+func (rg *RouteGroup) Use(middleware Middleware) {
+	rg.middlewares = append(rg.middlewares, middleware)
+}
+
+// Group creates a nested route group that inherits the group's prefix and middleware
+func (rg *RouteGroup) Group(prefix string) *RouteGroup {
+	return &RouteGroup{
+		server:      rg.server,
+		prefix:      strings.TrimSuffix(rg.buildFullPattern(prefix), "/"),
+		middlewares: append([]Middleware(nil), rg.middlewares...),
+	}
 }
 
 // Handle adds a route to the group (any method)
-func (rg *RouteGroup) Handle(pattern string, handler HTTPHandlerFunc) {
-	fullPattern := rg.buildFullPattern(pattern)
-	rg.server.Handle(fullPattern, handler)
+func (rg *RouteGroup) Handle(pattern string, handler HTTPHandlerFunc, middlewares ...Middleware) {
+	rg.server.addRoute("", rg.buildFullPattern(pattern), handler, rg.routeMiddlewares(middlewares))
 }
 
 // GET adds a GET route to the group
-func (rg *RouteGroup) GET(pattern string, handler HTTPHandlerFunc) {
-	fullPattern := rg.buildFullPattern(pattern)
-	rg.server.GET(fullPattern, handler)
+func (rg *RouteGroup) GET(pattern string, handler HTTPHandlerFunc, middlewares ...Middleware) {
+	rg.server.addRoute("GET", rg.buildFullPattern(pattern), handler, rg.routeMiddlewares(middlewares))
 }
 
 // POST adds a POST route to the group
-func (rg *RouteGroup) POST(pattern string, handler HTTPHandlerFunc) {
-	fullPattern := rg.buildFullPattern(pattern)
-	rg.server.POST(fullPattern, handler)
+func (rg *RouteGroup) POST(pattern string, handler HTTPHandlerFunc, middlewares ...Middleware) {
+	rg.server.addRoute("POST", rg.buildFullPattern(pattern), handler, rg.routeMiddlewares(middlewares))
 }
 
 // PUT adds a PUT route to the group
-func (rg *RouteGroup) PUT(pattern string, handler HTTPHandlerFunc) {
-	fullPattern := rg.buildFullPattern(pattern)
-	rg.server.PUT(fullPattern, handler)
+func (rg *RouteGroup) PUT(pattern string, handler HTTPHandlerFunc, middlewares ...Middleware) {
+	rg.server.addRoute("PUT", rg.buildFullPattern(pattern), handler, rg.routeMiddlewares(middlewares))
+}
+
+// PATCH adds a PATCH route to the group
+func (rg *RouteGroup) PATCH(pattern string, handler HTTPHandlerFunc, middlewares ...Middleware) {
+	rg.server.addRoute("PATCH", rg.buildFullPattern(pattern), handler, rg.routeMiddlewares(middlewares))
 }
 
 // DELETE adds a DELETE route to the group
-func (rg *RouteGroup) DELETE(pattern string, handler HTTPHandlerFunc) {
-	fullPattern := rg.buildFullPattern(pattern)
-	rg.server.DELETE(fullPattern, handler)
+func (rg *RouteGroup) DELETE(pattern string, handler HTTPHandlerFunc, middlewares ...Middleware) {
+	rg.server.addRoute("DELETE", rg.buildFullPattern(pattern), handler, rg.routeMiddlewares(middlewares))
+}
+
+// routeMiddlewares composes the group's middleware with route-specific middleware
+func (rg *RouteGroup) routeMiddlewares(middlewares []Middleware) []Middleware {
+	combined := make([]Middleware, 0, len(rg.middlewares)+len(middlewares))
+	combined = append(combined, rg.middlewares...)
+	return append(combined, middlewares...)
 }
 
 // buildFullPattern constructs the full pattern with prefix
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/logging"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/tracing"
+)
+
+// TracingMiddleware starts a server span for every request, continuing the
+// client's trace when a W3C traceparent header is present, and adds the trace
+// ID to the request's logs. It does nothing when tracing is not configured.
+func TracingMiddleware(next HTTPHandlerFunc) HTTPHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		ctx := tracing.Extract(r.Context(), r.Header)
+		ctx, span := tracing.Start(ctx, r.Method+" "+r.Pattern, tracing.KindServer,
+			"http.request.method", r.Method,
+			"http.route", r.Pattern,
+			"url.path", r.URL.Path,
+			"request_id", RequestID(r),
+		)
+		if span == nil {
+			return next(w, r)
+		}
+		defer span.End()
+		logging.AddFields(ctx, "trace_id", span.SpanContext().TraceID.String())
+
+		rec := &responseRecorder{ResponseWriter: w}
+		err := next(rec, r.WithContext(ctx))
+
+		status := rec.status
+		if err != nil {
+			status = AsHTTPError(err).Code
+		}
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes("http.response.status_code", status)
+		if status >= http.StatusInternalServerError {
+			span.SetError(http.StatusText(status))
+		}
+		return err
+	}
+}
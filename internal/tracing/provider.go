@@ -0,0 +1,164 @@
+package tracing
+
+import (
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Exporter sends ended spans to a tracing backend
+type Exporter interface {
+	// ExportSpans exports a batch of spans. It is never called concurrently.
+	ExportSpans(ctx context.Context, spans []SpanData) error
+	// Shutdown flushes and releases the exporter's resources
+	Shutdown(ctx context.Context) error
+}
+
+// Options configure span sampling and batching
+type Options struct {
+	SampleRatio   float64       // Fraction of new traces recorded; traces from clients follow the sampled flag
+	BatchSize     int           // Spans exported per batch
+	FlushInterval time.Duration // Longest a span waits before export
+	QueueSize     int           // Spans buffered before new ones are dropped
+}
+
+// Batching defaults for zero Options fields
+const (
+	defaultBatchSize     = 512
+	defaultFlushInterval = 5 * time.Second
+	defaultQueueSize     = 4096
+)
+
+// provider samples spans and exports them in batches from a background goroutine
+type provider struct {
+	exporter  Exporter
+	threshold uint64 // Traces whose ID sorts below this are sampled
+	opts      Options
+
+	queue   chan SpanData
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+// current is the active provider, or nil when tracing is disabled
+var current atomic.Pointer[provider]
+
+// Configure enables tracing, exporting spans through exporter. It replaces any
+// previous configuration without flushing it; call Shutdown first for that.
+func Configure(exporter Exporter, opts Options) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+
+	p := &provider{
+		exporter:  exporter,
+		threshold: sampleThreshold(opts.SampleRatio),
+		opts:      opts,
+		queue:     make(chan SpanData, opts.QueueSize),
+		done:      make(chan struct{}),
+	}
+	go p.run()
+	current.Store(p)
+}
+
+// Shutdown disables tracing, exports queued spans and shuts the exporter down
+func Shutdown(ctx context.Context) error {
+	p := current.Swap(nil)
+	if p == nil {
+		return nil
+	}
+
+	// Spans still being ended concurrently may be dropped
+	close(p.queue)
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if dropped := p.dropped.Load(); dropped > 0 {
+		slog.Warn("Dropped spans because the export queue was full", "spans", dropped)
+	}
+	return p.exporter.Shutdown(ctx)
+}
+
+// sampleThreshold converts a ratio into a threshold on the low 8 bytes of trace IDs
+func sampleThreshold(ratio float64) uint64 {
+	switch {
+	case ratio >= 1:
+		return math.MaxUint64
+	case ratio <= 0:
+		return 0
+	}
+	return uint64(ratio * math.MaxUint64)
+}
+
+// sample decides whether a new trace is recorded. The decision depends only on
+// the trace ID, so it is consistent wherever it is made.
+func (p *provider) sample(id TraceID) bool {
+	if p.threshold == math.MaxUint64 {
+		return true
+	}
+	return binary.BigEndian.Uint64(id[8:]) < p.threshold
+}
+
+// enqueue queues an ended span, dropping it if the queue is full
+func (p *provider) enqueue(span SpanData) {
+	defer func() {
+		// The queue was closed by Shutdown while this span was ending
+		if recover() != nil {
+			p.dropped.Add(1)
+		}
+	}()
+
+	select {
+	case p.queue <- span:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+// run exports spans in batches until the queue is closed
+func (p *provider) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, p.opts.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), p.opts.FlushInterval)
+		if err := p.exporter.ExportSpans(ctx, batch); err != nil {
+			slog.Warn("Failed to export spans", "spans", len(batch), "error", err)
+		}
+		cancel()
+		batch = make([]SpanData, 0, p.opts.BatchSize)
+	}
+
+	for {
+		select {
+		case span, ok := <-p.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= p.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace across services
+type TraceID [16]byte
+
+// String returns the ID as lowercase hex
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the ID is not all zeros
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+// String returns the ID as lowercase hex
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the ID is not all zeros
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// SpanContext is the part of a span propagated to children and other services
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid reports whether the context has both a trace and a span ID
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats the context as a W3C traceparent header value
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent parses a W3C traceparent header value. Unknown future
+// versions are accepted as long as they start with the version 00 fields.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	var sc SpanContext
+	var flags [1]byte
+	if !decodeHex(sc.TraceID[:], parts[1]) || !decodeHex(sc.SpanID[:], parts[2]) || !decodeHex(flags[:], parts[3]) {
+		return SpanContext{}, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.IsValid()
+}
+
+// decodeHex decodes lowercase hex of exactly len(dst) bytes
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != 2*len(dst) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// SpanKind describes a span's role, using the OTLP numbering
+type SpanKind int
+
+// Span kinds
+const (
+	KindInternal SpanKind = 1 // An operation within the server
+	KindServer   SpanKind = 2 // Handling of an incoming request
+	KindClient   SpanKind = 3 // An outgoing request or database call
+)
+
+// Attribute is a key-value pair describing a span
+type Attribute struct {
+	Key   string
+	Value interface{} // string, bool, int, int64 or float64
+}
+
+// SpanData is an ended span as passed to an Exporter
+type SpanData struct {
+	Name          string
+	Kind          SpanKind
+	TraceID       TraceID
+	SpanID        SpanID
+	ParentSpanID  SpanID // Zero for root spans
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	Error         bool   // Status is error
+	StatusMessage string // Describes the error
+}
+
+// Span is an operation being timed. A nil *Span is valid and records
+// nothing, so callers never need to check whether tracing is enabled.
+type Span struct {
+	sc       SpanContext
+	provider *provider // Nil for spans that are not sampled
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext returns the span's propagation context
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// IsRecording reports whether the span will be exported
+func (s *Span) IsRecording() bool {
+	return s != nil && s.provider != nil
+}
+
+// SetAttributes adds alternating key-value pairs to the span
+func (s *Span) SetAttributes(kv ...interface{}) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, ok := kv[i].(string)
+		if !ok {
+			continue
+		}
+		s.data.Attributes = append(s.data.Attributes, Attribute{Key: key, Value: kv[i+1]})
+	}
+}
+
+// SetError marks the span as failed with message
+func (s *Span) SetError(message string) {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Error = true
+	s.data.StatusMessage = message
+}
+
+// RecordError marks the span as failed if err is not nil
+func (s *Span) RecordError(err error) {
+	if err != nil {
+		s.SetError(err.Error())
+	}
+}
+
+// End finishes the span and queues it for export. Later calls do nothing.
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.provider.enqueue(data)
+}
+
+// spanKey is the context key of the current span
+type spanKey struct{}
+
+// remoteKey is the context key of a parent span context received from a client
+type remoteKey struct{}
+
+// SpanFromContext returns the current span, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteParent returns a context whose next span continues the
+// trace described by sc, typically extracted from an incoming request
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// parentContext returns the span context new spans in ctx are children of
+func parentContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.sc
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// Start begins a span as a child of the span in ctx, or of a remote parent,
+// and returns a context carrying it. When tracing is not configured it returns
+// ctx and a nil span.
+func Start(ctx context.Context, name string, kind SpanKind, kv ...interface{}) (context.Context, *Span) {
+	p := current.Load()
+	if p == nil {
+		return ctx, nil
+	}
+
+	parent := parentContext(ctx)
+	span := &Span{}
+	if parent.IsValid() {
+		span.sc.TraceID = parent.TraceID
+		span.sc.Sampled = parent.Sampled
+	} else {
+		rand.Read(span.sc.TraceID[:])
+		span.sc.Sampled = p.sample(span.sc.TraceID)
+	}
+	rand.Read(span.sc.SpanID[:])
+
+	if span.sc.Sampled {
+		span.provider = p
+		span.data = SpanData{
+			Name:         name,
+			Kind:         kind,
+			TraceID:      span.sc.TraceID,
+			SpanID:       span.sc.SpanID,
+			ParentSpanID: parent.SpanID,
+			Start:        time.Now(),
+		}
+		span.SetAttributes(kv...)
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Extract returns ctx with the remote parent from a traceparent header, if valid
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, ok := ParseTraceparent(header.Get("traceparent"))
+	if !ok {
+		return ctx
+	}
+	return ContextWithRemoteParent(ctx, sc)
+}
+
+// Inject sets the traceparent header for an outgoing request made within ctx
+func Inject(ctx context.Context, header http.Header) {
+	if sc := parentContext(ctx); sc.IsValid() {
+		header.Set("traceparent", sc.Traceparent())
+	}
+}
@@ -0,0 +1,139 @@
+// Package pebbledb embeds the PebbleDB server in other Go programs. Register
+// custom /api/db actions and an authorizer, then call Main:
+//
+//	func main() {
+//		if err := pebbledb.RegisterAction(pebbledb.Action{...}); err != nil {
+//			log.Fatal(err)
+//		}
+//		pebbledb.Main()
+//	}
+package pebbledb
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/handlers"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/openapi"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/ArnavChoudhary9/PebbleDB/pkg/types"
+)
+
+// Action registry types
+type (
+	// Action is an operation served by the /api/db endpoint, selected by the
+	// request's action field
+	Action = handlers.Action
+	// ActionDoc describes an action in the OpenAPI document
+	ActionDoc = handlers.ActionDoc
+	// ActionHandler runs an action. db is the project database for actions
+	// that need one, and nil otherwise.
+	ActionHandler = handlers.ActionHandler
+	// ActionAuthorizer decides whether a request may run an action
+	ActionAuthorizer = handlers.ActionAuthorizer
+	// Permission is an access right an action requires
+	Permission = handlers.Permission
+)
+
+// Permissions of actions
+const (
+	PermissionRead     = handlers.PermissionRead     // Read projects, schemas and rows
+	PermissionWrite    = handlers.PermissionWrite    // Insert, update and delete rows
+	PermissionSchema   = handlers.PermissionSchema   // Create and drop tables
+	PermissionProjects = handlers.PermissionProjects // Create and delete projects
+)
+
+// DB is a project database, as passed to action handlers
+type DB = database.DB
+
+// Schema is an OpenAPI schema describing an action's response data
+type Schema = openapi.Schema
+
+// HTTPError is an error with an HTTP status code and a machine-readable error
+// code, returned by action handlers to fail a request
+type HTTPError = server.HTTPError
+
+// RegisterAction adds an action to the /api/db endpoint. Actions must be
+// registered before Main, and names must be unique.
+func RegisterAction(action Action) error {
+	return handlers.RegisterAction(action)
+}
+
+// SetActionAuthorizer installs the permission check run before every action.
+// It must be called before Main.
+func SetActionAuthorizer(authorizer ActionAuthorizer) {
+	handlers.SetActionAuthorizer(authorizer)
+}
+
+// WriteSuccess writes a successful response envelope with data
+func WriteSuccess(w http.ResponseWriter, data interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(types.JSONResponse{Success: true, Data: data})
+}
+
+// Errors for action handlers
+
+// BadRequest creates a 400 error
+func BadRequest(message string) HTTPError {
+	return server.BadRequest(message)
+}
+
+// NotFound creates a 404 error
+func NotFound(message string) HTTPError {
+	return server.NotFound(message)
+}
+
+// Forbidden creates a 403 error
+func Forbidden(message string) HTTPError {
+	return server.Forbidden(message)
+}
+
+// QueryError converts an error from a database operation into an HTTP error,
+// with a specific error code for SQLite errors clients can act on. message
+// describes the failed operation, e.g. "Failed to vacuum".
+func QueryError(message string, err error) error {
+	return database.QueryError(message, err)
+}
+
+// Schema helpers for ActionDoc.Data
+
+// Ref refers to a schema in the document's components, e.g. "Rows"
+func Ref(name string) *Schema {
+	return openapi.Ref(name)
+}
+
+// String returns a string schema
+func String(description string) *Schema {
+	return openapi.String(description)
+}
+
+// Integer returns a 64-bit integer schema
+func Integer(description string) *Schema {
+	return openapi.Integer(description)
+}
+
+// Boolean returns a boolean schema
+func Boolean(description string) *Schema {
+	return openapi.Boolean(description)
+}
+
+// Array returns an array schema of items
+func Array(items *Schema, description string) *Schema {
+	return openapi.Array(items, description)
+}
+
+// Map returns an object schema whose property values all match values
+func Map(values *Schema, description string) *Schema {
+	return openapi.Map(values, description)
+}
+
+// Object returns an object schema with the given properties
+func Object(properties map[string]*Schema, required ...string) *Schema {
+	return openapi.Object(properties, required...)
+}
+
+// Any returns a schema that accepts any value
+func Any(description string) *Schema {
+	return openapi.Any(description)
+}
@@ -0,0 +1,186 @@
+package pebbledb
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ArnavChoudhary9/PebbleDB/internal/config"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/database"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/handlers"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/logging"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/server"
+	"github.com/ArnavChoudhary9/PebbleDB/internal/tracing"
+)
+
+// configWatchInterval is how often the config file is checked for changes
+const configWatchInterval = 5 * time.Second
+
+// handlerGracePeriod is how long shutdown waits for handlers still running
+// after their connections were closed forcibly
+const handlerGracePeriod = 5 * time.Second
+
+// Main runs the PebbleDB server with the built-in and registered actions. It
+// reads the configuration from the command line, the environment and the
+// config file, serves until SIGINT or SIGTERM and then exits the process.
+func Main() {
+	// Load configuration
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		fatal(err)
+	}
+	if cfg.PrintConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fatal(fmt.Errorf("failed to print configuration: %w", err))
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		fatal(err)
+	}
+	if err := logging.Configure(os.Stderr, cfg.Logging.Level, cfg.Logging.Format); err != nil {
+		fatal(fmt.Errorf("failed to configure logging: %w", err))
+	}
+	if !cfg.Auth.Enabled {
+		slog.Warn("Authentication is disabled; all requests run as the development user", "user", cfg.Auth.DevUser)
+	}
+
+	if cfg.Tracing.Enabled {
+		exporter, err := tracing.NewOTLPExporter(cfg.Tracing.Endpoint, cfg.Tracing.ServiceName)
+		if err != nil {
+			fatal(fmt.Errorf("failed to configure tracing: %w", err))
+		}
+		tracing.Configure(exporter, tracing.Options{SampleRatio: cfg.Tracing.SampleRatio})
+		slog.Info("Tracing enabled", "endpoint", cfg.Tracing.Endpoint, "sample_ratio", cfg.Tracing.SampleRatio)
+	}
+
+	database.SetProjectDBConfig(database.Config{
+		MaxOpenConns:    cfg.Storage.MaxOpenConns,
+		MaxIdleConns:    cfg.Storage.MaxIdleConns,
+		ConnMaxLifetime: cfg.Storage.ConnMaxLifetime,
+		WALMode:         cfg.Storage.WALMode,
+		ForeignKeys:     cfg.Storage.ForeignKeys,
+	})
+	if cfg.Metrics.PerProjectPools {
+		database.EnablePerProjectPoolMetrics()
+	}
+
+	// Create server instance
+	srv := server.NewServer()
+
+	// Setup routes and middleware
+	handlers.SetupRoutes(srv, cfg)
+
+	// Background tasks run until shutdown
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Start scheduled backups; shutdown waits for a running backup
+	var backups sync.WaitGroup
+	if cfg.Backups.Enabled {
+		slog.Info("Scheduled backups enabled", "dir", cfg.Backups.Dir, "interval", cfg.Backups.Interval.String())
+		backups.Add(1)
+		go func() {
+			defer backups.Done()
+			database.RunBackups(backgroundCtx, database.BackupSchedule{
+				DataDir:  cfg.Storage.DataDir,
+				Dir:      cfg.Backups.Dir,
+				Interval: cfg.Backups.Interval,
+				Retain:   cfg.Backups.Retain,
+			})
+		}()
+	}
+
+	// Start server
+	listener := server.ListenerConfig{
+		Addr:         cfg.Server.ListenAddr,
+		UnixSocket:   cfg.Server.UnixSocket,
+		TLSCertFile:  cfg.Server.TLSCertFile,
+		TLSKeyFile:   cfg.Server.TLSKeyFile,
+		ClientCAFile: cfg.Server.TLSClientCAFile,
+	}
+	slog.Info("Starting PebbleDB server", "listener", listener.String())
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- srv.Listen(listener)
+	}()
+
+	// Reload configuration on SIGHUP or when the config file changes
+	manager := config.NewManager(cfg, os.Args[1:])
+	manager.OnReload(handlers.ApplyConfig)
+	manager.OnReload(func(cfg *config.Config) {
+		logging.SetLevel(cfg.Logging.Level)
+	})
+	go manager.Watch(backgroundCtx, configWatchInterval)
+
+	// Wait for a termination signal or a server failure
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	exitCode := 0
+wait:
+	for {
+		select {
+		case err := <-serverErr:
+			if err != nil {
+				slog.Error("Failed to start server", "error", err)
+				exitCode = 1
+			}
+			break wait
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				slog.Info("Reloading configuration", "signal", sig.String())
+				manager.ReloadAndLog()
+				continue
+			}
+
+			slog.Info("Shutting down", "signal", sig.String())
+			signal.Stop(signals)
+
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+			if err := srv.Shutdown(ctx); err != nil {
+				slog.Error("Aborted requests still running at the shutdown deadline", "timeout", cfg.Server.ShutdownTimeout.String(), "error", err)
+				exitCode = 1
+			}
+			cancel()
+			break wait
+		}
+	}
+	stopBackground()
+	backups.Wait()
+
+	// Export spans of the requests that just finished
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := tracing.Shutdown(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+	cancel()
+
+	// Checkpoint and close project databases once no handler can use them.
+	// Handlers cut off by a forced shutdown get a moment to notice; if they
+	// are still running, the databases are left for the process exit to close.
+	ctx, cancel = context.WithTimeout(context.Background(), handlerGracePeriod)
+	if err := srv.WaitForHandlers(ctx); err != nil {
+		slog.Error("Requests still running; leaving project databases open", "error", err)
+		exitCode = 1
+	} else if err := database.CloseAllProjectDBs(); err != nil {
+		slog.Error("Failed to close project databases", "error", err)
+		exitCode = 1
+	}
+	cancel()
+
+	slog.Info("Server stopped")
+	os.Exit(exitCode)
+}
+
+// fatal reports a startup error and exits. It writes plain text because the
+// logger is not configured until the configuration has been loaded.
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -10,6 +10,8 @@ const (
 	DatabaseContextKey ContextKey = "database"
 	// WorkingDirectoryContextKey is used to store working directory in context
 	WorkingDirectoryContextKey ContextKey = "working_directory"
+	// RequestIDContextKey is used to store the request ID in context
+	RequestIDContextKey ContextKey = "request_id"
 )
 
 // Project represents a database project
@@ -47,6 +49,13 @@ type JSONRequest struct {
 	Having    string                 `json:"having,omitempty"`
 	Schema    map[string]interface{} `json:"schema,omitempty"`
 	Joins     []JSONJoin             `json:"joins,omitempty"`
+	Format    string                 `json:"format,omitempty"` // Export format: "csv", "ndjson" or "sql"
+	Stream    string                 `json:"stream,omitempty"` // Streaming mode: "json" or "ndjson"
+	// Strict coerces and validates written values against the table schema,
+	// and makes create_table create a STRICT table
+	Strict bool `json:"strict,omitempty"`
+	// ResultFormat selects "objects" (default) or "typed" rows with a column header
+	ResultFormat string `json:"result_format,omitempty"`
 	// Project-specific fields
 	ProjectName        string `json:"project_name,omitempty"`
 	ProjectDescription string `json:"project_description,omitempty"`
@@ -57,9 +66,16 @@ type JSONResponse struct {
 	Success bool        `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`
-	Count   int64       `json:"count,omitempty"`
-	ID      int64       `json:"id,omitempty"`
-	Query   string      `json:"query,omitempty"` // Optional: show generated query for debugging
+	// ErrorCode is a stable machine-readable code accompanying Error
+	ErrorCode string `json:"error_code,omitempty"`
+	// ErrorDetails carries structured context for some error codes, such as
+	// the constraint and column of a constraint violation
+	ErrorDetails map[string]string `json:"error_details,omitempty"`
+	Count        int64             `json:"count,omitempty"`
+	ID           int64             `json:"id,omitempty"`
+	Query        string            `json:"query,omitempty"` // Optional: show generated query for debugging
+	// Truncated is set when a streamed result hit the server-side row cap
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // RefreshTokenRequest represents the refresh token request payload